@@ -0,0 +1,71 @@
+package log
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog"
+)
+
+// setLevelRequest is the body SetLevelHandler expects. TTL is a duration string as understood by time.ParseDuration,
+// for example "15m". It's optional: without it the level stays until it's changed again.
+type setLevelRequest struct {
+	Level string `json:"level"`
+	TTL   string `json:"ttl"`
+}
+
+// RegisterAdminRoutes adds the routes to list and change logger levels to the passed in echo group:
+//   - GET  <group>/loggers       - ListLevelsHandler
+//   - PUT  <group>/loggers/:name - SetLevelHandler
+//
+// These routes change how the service behaves, so the group should only be reachable from the inside, or have an
+// authentication middleware on it.
+func (r *Registry) RegisterAdminRoutes(g *echo.Group) {
+	g.GET("/loggers", r.ListLevelsHandler())
+	g.PUT("/loggers/:name", r.SetLevelHandler())
+}
+
+// ListLevelsHandler returns an echo handler that responds with the current levels of every named logger as a JSON
+// array.
+func (r *Registry) ListLevelsHandler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		return c.JSON(http.StatusOK, r.Levels())
+	}
+}
+
+// SetLevelHandler returns an echo handler that changes the level of the logger named in the :name path parameter. The
+// request body looks like {"level":"debug","ttl":"15m"}. It responds with the new state of the logger.
+func (r *Registry) SetLevelHandler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		name := c.Param("name")
+		if name == "" {
+			return echo.NewHTTPError(http.StatusBadRequest, "logger name is required")
+		}
+
+		var req setLevelRequest
+		if err := c.Bind(&req); err != nil {
+			return err
+		}
+
+		level, err := zerolog.ParseLevel(strings.ToLower(req.Level))
+		if err != nil || req.Level == "" {
+			return echo.NewHTTPError(http.StatusBadRequest, "unknown level").SetInternal(err)
+		}
+
+		var ttl time.Duration
+		if req.TTL != "" {
+			ttl, err = time.ParseDuration(req.TTL)
+			if err != nil || ttl < 0 {
+				return echo.NewHTTPError(http.StatusBadRequest, "ttl is not a valid duration").SetInternal(err)
+			}
+		}
+
+		r.SetLevel(name, level, ttl)
+
+		ll, _ := r.Level(name)
+
+		return c.JSON(http.StatusOK, ll)
+	}
+}
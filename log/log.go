@@ -0,0 +1,200 @@
+// Package log builds zerolog loggers in a standard way, so every service that uses the kit produces log lines with the
+// same shape: same field names, same time format, same base fields.
+package log
+
+import (
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+const (
+	// FormatJSON outputs every log line as a JSON object. This is the default.
+	FormatJSON = "json"
+
+	// FormatConsole outputs human friendly, colorized log lines. Useful for local development, slow in production.
+	FormatConsole = "console"
+
+	// Field names of the base fields every log line carries.
+	ServiceFieldName   = "service"
+	NamespaceFieldName = "namespace"
+	VersionFieldName   = "version"
+)
+
+// Config holds everything needed to build a logger.
+//
+// ServiceName, ServiceNamespace and ServiceVersion should be set to the same values as the observability.MeterConfig,
+// so logs and metrics of the same service can be matched. Empty values are left out of the log lines.
+type Config struct {
+	// Level is the minimum level to log at, for example "debug", "info", "warn". Defaults to "info".
+	Level string
+
+	// Format is either FormatJSON or FormatConsole. Defaults to FormatJSON.
+	Format string
+
+	// Caller adds the file and line number of the log call to every log line.
+	Caller bool
+
+	ServiceName      string
+	ServiceNamespace string
+	ServiceVersion   string
+
+	// Output is where the log lines are written to. Defaults to os.Stderr. Use zerolog.MultiLevelWriter to write to more
+	// than one place, for example both to os.Stderr and to the observability.LogWriter.
+	Output io.Writer
+
+	// Sampling is optional. If nil, every log line is written.
+	Sampling *SamplingConfig
+}
+
+// SamplingConfig configures zerolog's burst and level samplers.
+//
+// The per level values mean only one in every N log lines on that level is written. Without a burst, zero and one both
+// mean every log line is written.
+//
+// If Burst is set, the first Burst log lines in every Period are always written, and the ones over that are handed to
+// the level samplers. In that case a zero level value means every log line on that level over the burst is dropped.
+//
+// Fatal and panic levels are never sampled.
+type SamplingConfig struct {
+	Burst  uint32
+	Period time.Duration
+
+	Trace uint32
+	Debug uint32
+	Info  uint32
+	Warn  uint32
+	Error uint32
+}
+
+// New returns a logger configured according to the passed in Config. Its timestamps are RFC3339 with nanoseconds, so
+// they look the same in every service. zerolog's global time format is left alone, it only applies to other loggers.
+func New(config Config) (zerolog.Logger, error) {
+	level := zerolog.InfoLevel
+	if config.Level != "" {
+		l, err := zerolog.ParseLevel(strings.ToLower(config.Level))
+		if err != nil {
+			return zerolog.Nop(), errors.Wrap(err, "zerolog.ParseLevel")
+		}
+
+		level = l
+	}
+
+	var out io.Writer = os.Stderr
+	if config.Output != nil {
+		out = config.Output
+	}
+
+	switch config.Format {
+	case "", FormatJSON:
+	case FormatConsole:
+		out = zerolog.ConsoleWriter{
+			Out:        out,
+			TimeFormat: time.RFC3339,
+		}
+	default:
+		return zerolog.Nop(), errors.Errorf("unknown log format %q, use one of %q or %q", config.Format, FormatJSON,
+			FormatConsole)
+	}
+
+	lc := zerolog.New(out).Level(level).Hook(timestampHook{}).With()
+
+	if config.Caller {
+		lc = lc.Caller()
+	}
+
+	for _, f := range []struct {
+		name, value string
+	}{
+		{ServiceFieldName, config.ServiceName},
+		{NamespaceFieldName, config.ServiceNamespace},
+		{VersionFieldName, config.ServiceVersion},
+	} {
+		if f.value != "" {
+			lc = lc.Str(f.name, f.value)
+		}
+	}
+
+	l := lc.Logger()
+
+	if config.Sampling != nil {
+		s, err := config.Sampling.sampler()
+		if err != nil {
+			return zerolog.Nop(), errors.Wrap(err, "config.Sampling.sampler")
+		}
+
+		l = l.Sample(s)
+	}
+
+	return l, nil
+}
+
+// timestampHook adds the time of the event to it as an RFC3339 timestamp with nanoseconds, the way zerolog's
+// Timestamp does with the global time format.
+type timestampHook struct{}
+
+// Run adds the timestamp field to the event.
+func (timestampHook) Run(e *zerolog.Event, _ zerolog.Level, _ string) {
+	e.Str(zerolog.TimestampFieldName, zerolog.TimestampFunc().Format(time.RFC3339Nano))
+}
+
+// sampler builds the zerolog.Sampler described by the SamplingConfig.
+func (s SamplingConfig) sampler() (zerolog.Sampler, error) {
+	if s.Burst == 0 {
+		return zerolog.LevelSampler{
+			TraceSampler: basicSampler(s.Trace),
+			DebugSampler: basicSampler(s.Debug),
+			InfoSampler:  basicSampler(s.Info),
+			WarnSampler:  basicSampler(s.Warn),
+			ErrorSampler: basicSampler(s.Error),
+		}, nil
+	}
+
+	if s.Period <= 0 {
+		return nil, errors.New("sampling period needs to be set when burst is set")
+	}
+
+	return &zerolog.BurstSampler{
+		Burst:  s.Burst,
+		Period: s.Period,
+		NextSampler: zerolog.LevelSampler{
+			TraceSampler: overBurstSampler(s.Trace),
+			DebugSampler: overBurstSampler(s.Debug),
+			InfoSampler:  overBurstSampler(s.Info),
+			WarnSampler:  overBurstSampler(s.Warn),
+			ErrorSampler: overBurstSampler(s.Error),
+		},
+	}, nil
+}
+
+// basicSampler returns a sampler that lets one in every n log lines through, or nil if every line should be let through.
+// zerolog's BasicSampler panics on a zero N, so that's treated the same as one.
+func basicSampler(n uint32) zerolog.Sampler {
+	if n <= 1 {
+		return nil
+	}
+
+	return &zerolog.BasicSampler{N: n}
+}
+
+// overBurstSampler returns the sampler for log lines over the burst: one in every n is let through, or none of them if
+// n is zero.
+func overBurstSampler(n uint32) zerolog.Sampler {
+	if n == 0 {
+		return dropSampler{}
+	}
+
+	return &zerolog.BasicSampler{N: n}
+}
+
+// dropSampler is a zerolog.Sampler that drops every log line.
+type dropSampler struct{}
+
+// Sample implements zerolog.Sampler.
+func (dropSampler) Sample(zerolog.Level) bool {
+	return false
+}
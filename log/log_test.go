@@ -0,0 +1,209 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name       string
+		config     Config
+		wantErr    bool
+		wantFields map[string]interface{}
+		wantEmpty  bool
+	}{
+		{
+			name: "defaults with base fields",
+			config: Config{
+				ServiceName:      "my-service",
+				ServiceNamespace: "production",
+				ServiceVersion:   "v0.0.1",
+			},
+			wantFields: map[string]interface{}{
+				"level":     "info",
+				"message":   "hello",
+				"service":   "my-service",
+				"namespace": "production",
+				"version":   "v0.0.1",
+			},
+		},
+		{
+			name: "empty base fields are left out",
+			config: Config{
+				ServiceName: "my-service",
+			},
+			wantFields: map[string]interface{}{
+				"level":   "info",
+				"message": "hello",
+				"service": "my-service",
+			},
+		},
+		{
+			name: "level from upper case string",
+			config: Config{
+				Level: "WARN",
+			},
+			wantEmpty: true,
+		},
+		{
+			name: "unknown level",
+			config: Config{
+				Level: "loud",
+			},
+			wantErr: true,
+		},
+		{
+			name: "unknown format",
+			config: Config{
+				Format: "xml",
+			},
+			wantErr: true,
+		},
+		{
+			name: "burst without period",
+			config: Config{
+				Sampling: &SamplingConfig{
+					Burst: 5,
+				},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := bytes.NewBuffer(nil)
+			tt.config.Output = b
+
+			l, err := New(tt.config)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+
+			l.Info().Msg("hello")
+
+			if tt.wantEmpty {
+				assert.Empty(t, b.String())
+				return
+			}
+
+			fields := make(map[string]interface{})
+			require.NoError(t, json.Unmarshal(b.Bytes(), &fields))
+
+			ts, ok := fields["time"].(string)
+			require.True(t, ok, "time field should be a string")
+
+			_, err = time.Parse(time.RFC3339Nano, ts)
+			assert.NoError(t, err)
+
+			delete(fields, "time")
+			assert.Equal(t, tt.wantFields, fields)
+		})
+	}
+}
+
+func TestNew_caller(t *testing.T) {
+	b := bytes.NewBuffer(nil)
+
+	l, err := New(Config{Output: b, Caller: true})
+	require.NoError(t, err)
+
+	l.Info().Msg("hello")
+
+	assert.Contains(t, b.String(), "log_test.go")
+}
+
+func TestNew_timeFormat(t *testing.T) {
+	before := zerolog.TimeFieldFormat
+	zerolog.TimeFieldFormat = zerolog.TimeFormatUnix
+	defer func() {
+		zerolog.TimeFieldFormat = before
+	}()
+
+	b := bytes.NewBuffer(nil)
+
+	l, err := New(Config{Output: b})
+	require.NoError(t, err)
+
+	l.Info().Msg("hello")
+
+	assert.Equal(t, zerolog.TimeFormatUnix, zerolog.TimeFieldFormat, "global time format should be left alone")
+
+	fields := make(map[string]interface{})
+	require.NoError(t, json.Unmarshal(b.Bytes(), &fields))
+
+	ts, ok := fields["time"].(string)
+	require.True(t, ok, "time field should be a string")
+
+	_, err = time.Parse(time.RFC3339Nano, ts)
+	assert.NoError(t, err)
+}
+
+func TestNew_console(t *testing.T) {
+	b := bytes.NewBuffer(nil)
+
+	l, err := New(Config{Output: b, Format: FormatConsole})
+	require.NoError(t, err)
+
+	l.Info().Msg("hello")
+
+	assert.Contains(t, b.String(), "hello")
+	assert.False(t, strings.HasPrefix(b.String(), "{"), "console output should not be json")
+}
+
+func TestNew_sampling(t *testing.T) {
+	tests := []struct {
+		name     string
+		sampling SamplingConfig
+		want     int
+	}{
+		{
+			name: "one in every five info lines",
+			sampling: SamplingConfig{
+				Info: 5,
+			},
+			want: 20,
+		},
+		{
+			name: "burst of ten, drop the rest",
+			sampling: SamplingConfig{
+				Burst:  10,
+				Period: time.Hour,
+			},
+			want: 10,
+		},
+		{
+			name: "burst of ten, one in every ten over the burst",
+			sampling: SamplingConfig{
+				Burst:  10,
+				Period: time.Hour,
+				Info:   10,
+			},
+			want: 19,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := bytes.NewBuffer(nil)
+
+			l, err := New(Config{Output: b, Sampling: &tt.sampling})
+			require.NoError(t, err)
+
+			for i := 0; i < 100; i++ {
+				l.Info().Msg("hello")
+			}
+
+			assert.Equal(t, tt.want, strings.Count(b.String(), "\n"))
+		})
+	}
+}
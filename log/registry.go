@@ -0,0 +1,181 @@
+package log
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// LoggerFieldName is the field name that holds the name of the logger in every log line written by a named logger.
+const LoggerFieldName = "logger"
+
+// Registry hands out named loggers whose levels can be changed at runtime, one name at a time. This makes it possible
+// to turn on debug logs for one subsystem in production without turning them on everywhere.
+//
+// Every named logger is a child of the base logger passed to NewRegistry, so it has the same output, fields and
+// sampling. The level of the named loggers starts out as the level of the base logger. Levels are still subject to
+// zerolog's global level.
+//
+// Log events on levels that are turned off are discarded by a hook, which means their fields are still evaluated. This
+// is the price of being able to change the level of loggers that have already been handed out.
+type Registry struct {
+	base         zerolog.Logger
+	defaultLevel zerolog.Level
+
+	mu      sync.Mutex
+	loggers map[string]*namedLevel
+}
+
+// namedLevel holds the current level of a named logger, and the state needed to revert it.
+type namedLevel struct {
+	level atomic.Int32
+
+	// the fields below are guarded by the registry's mutex.
+	revertAt   time.Time
+	revert     *time.Timer
+	generation uint64
+}
+
+// LoggerLevel describes the current level of one named logger.
+type LoggerLevel struct {
+	Name     string     `json:"name"`
+	Level    string     `json:"level"`
+	RevertAt *time.Time `json:"revertAt,omitempty"`
+}
+
+// NewRegistry returns a Registry that creates named loggers from the passed in base logger, for example one returned
+// by New.
+func NewRegistry(base zerolog.Logger) *Registry {
+	return &Registry{
+		base:         base,
+		defaultLevel: base.GetLevel(),
+		loggers:      make(map[string]*namedLevel),
+	}
+}
+
+// Logger returns the logger with the passed in name. Loggers with the same name share their level, so it's fine to call
+// this more than once with the same name, for example once per package.
+func (r *Registry) Logger(name string) zerolog.Logger {
+	nl := r.get(name)
+
+	return r.base.Level(zerolog.TraceLevel).
+		With().
+		Str(LoggerFieldName, name).
+		Logger().
+		Hook(levelHook{level: &nl.level})
+}
+
+// SetLevel changes the level of the named logger. If the ttl is larger than zero, the level reverts to the default
+// level of the registry once it passes. Setting a level for a name that doesn't have a logger yet is fine, loggers with
+// that name created later will start with that level.
+func (r *Registry) SetLevel(name string, level zerolog.Level, ttl time.Duration) {
+	nl := r.get(name)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	nl.level.Store(int32(level))
+	nl.generation++
+
+	if nl.revert != nil {
+		nl.revert.Stop()
+		nl.revert = nil
+	}
+
+	nl.revertAt = time.Time{}
+
+	if ttl <= 0 {
+		return
+	}
+
+	generation := nl.generation
+	nl.revertAt = time.Now().Add(ttl)
+	nl.revert = time.AfterFunc(ttl, func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		// the level was changed again since this timer was set up, that change wins.
+		if nl.generation != generation {
+			return
+		}
+
+		nl.level.Store(int32(r.defaultLevel))
+		nl.revert = nil
+		nl.revertAt = time.Time{}
+	})
+}
+
+// Levels returns the current level of every named logger, sorted by name.
+func (r *Registry) Levels() []LoggerLevel {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	levels := make([]LoggerLevel, 0, len(r.loggers))
+	for name, nl := range r.loggers {
+		levels = append(levels, nl.describe(name))
+	}
+
+	sort.Slice(levels, func(i, j int) bool {
+		return levels[i].Name < levels[j].Name
+	})
+
+	return levels
+}
+
+// Level returns the current level of the named logger, and false if there's no logger with that name.
+func (r *Registry) Level(name string) (LoggerLevel, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	nl, ok := r.loggers[name]
+	if !ok {
+		return LoggerLevel{}, false
+	}
+
+	return nl.describe(name), true
+}
+
+// get returns the level holder for the name, creating it with the default level if needed.
+func (r *Registry) get(name string) *namedLevel {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	nl, ok := r.loggers[name]
+	if !ok {
+		nl = &namedLevel{}
+		nl.level.Store(int32(r.defaultLevel))
+		r.loggers[name] = nl
+	}
+
+	return nl
+}
+
+// describe returns the current state of the named level. The registry's mutex needs to be held.
+func (nl *namedLevel) describe(name string) LoggerLevel {
+	ll := LoggerLevel{
+		Name:  name,
+		Level: zerolog.Level(nl.level.Load()).String(),
+	}
+
+	if !nl.revertAt.IsZero() {
+		revertAt := nl.revertAt
+		ll.RevertAt = &revertAt
+	}
+
+	return ll
+}
+
+// levelHook discards events below the current level of a named logger.
+type levelHook struct {
+	level *atomic.Int32
+}
+
+// Run implements zerolog.Hook.
+func (h levelHook) Run(e *zerolog.Event, level zerolog.Level, _ string) {
+	if level < zerolog.Level(h.level.Load()) {
+		e.Discard()
+	}
+}
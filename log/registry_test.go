@@ -0,0 +1,182 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry(t *testing.T) {
+	b := bytes.NewBuffer(nil)
+	r := NewRegistry(zerolog.New(b).Level(zerolog.InfoLevel))
+
+	db := r.Logger("db")
+	api := r.Logger("api")
+
+	db.Debug().Msg("db debug before")
+	api.Debug().Msg("api debug before")
+	assert.Empty(t, b.String(), "debug lines should be discarded at the default info level")
+
+	r.SetLevel("db", zerolog.DebugLevel, 0)
+
+	db.Debug().Msg("db debug after")
+	api.Debug().Msg("api debug after")
+
+	assert.Contains(t, b.String(), "db debug after")
+	assert.Contains(t, b.String(), `"logger":"db"`)
+	assert.NotContains(t, b.String(), "api debug after")
+
+	b.Reset()
+
+	r.SetLevel("api", zerolog.ErrorLevel, 0)
+	api.Warn().Msg("api warn")
+	api.Error().Msg("api error")
+
+	assert.NotContains(t, b.String(), "api warn")
+	assert.Contains(t, b.String(), "api error")
+
+	assert.Equal(t, []LoggerLevel{
+		{Name: "api", Level: "error"},
+		{Name: "db", Level: "debug"},
+	}, r.Levels())
+}
+
+func TestRegistry_levelBeforeLogger(t *testing.T) {
+	b := bytes.NewBuffer(nil)
+	r := NewRegistry(zerolog.New(b).Level(zerolog.InfoLevel))
+
+	r.SetLevel("later", zerolog.DebugLevel, 0)
+
+	l := r.Logger("later")
+	l.Debug().Msg("hello")
+	assert.Contains(t, b.String(), "hello")
+}
+
+func TestRegistry_ttl(t *testing.T) {
+	b := bytes.NewBuffer(nil)
+	r := NewRegistry(zerolog.New(b).Level(zerolog.InfoLevel))
+
+	l := r.Logger("db")
+	r.SetLevel("db", zerolog.DebugLevel, 20*time.Millisecond)
+
+	ll, ok := r.Level("db")
+	require.True(t, ok)
+	assert.Equal(t, "debug", ll.Level)
+	assert.NotNil(t, ll.RevertAt)
+
+	assert.Eventually(t, func() bool {
+		ll, _ := r.Level("db")
+		return ll.Level == "info" && ll.RevertAt == nil
+	}, time.Second, 5*time.Millisecond)
+
+	l.Debug().Msg("after revert")
+	assert.Empty(t, b.String())
+}
+
+func TestRegistry_ttlOverridden(t *testing.T) {
+	r := NewRegistry(zerolog.New(nil).Level(zerolog.InfoLevel))
+
+	r.SetLevel("db", zerolog.DebugLevel, 10*time.Millisecond)
+	r.SetLevel("db", zerolog.TraceLevel, 0)
+
+	time.Sleep(30 * time.Millisecond)
+
+	ll, _ := r.Level("db")
+	assert.Equal(t, "trace", ll.Level)
+}
+
+func TestRegistry_adminRoutes(t *testing.T) {
+	r := NewRegistry(zerolog.New(nil).Level(zerolog.InfoLevel))
+	_ = r.Logger("db")
+
+	e := echo.New()
+	r.RegisterAdminRoutes(e.Group("/admin"))
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+		wantLevel  string
+	}{
+		{
+			name:       "set level",
+			method:     http.MethodPut,
+			path:       "/admin/loggers/db",
+			body:       `{"level":"debug"}`,
+			wantStatus: http.StatusOK,
+			wantLevel:  "debug",
+		},
+		{
+			name:       "set level with ttl",
+			method:     http.MethodPut,
+			path:       "/admin/loggers/db",
+			body:       `{"level":"WARN","ttl":"1h"}`,
+			wantStatus: http.StatusOK,
+			wantLevel:  "warn",
+		},
+		{
+			name:       "unknown level",
+			method:     http.MethodPut,
+			path:       "/admin/loggers/db",
+			body:       `{"level":"loud"}`,
+			wantStatus: http.StatusBadRequest,
+			wantLevel:  "warn",
+		},
+		{
+			name:       "missing level",
+			method:     http.MethodPut,
+			path:       "/admin/loggers/db",
+			body:       `{}`,
+			wantStatus: http.StatusBadRequest,
+			wantLevel:  "warn",
+		},
+		{
+			name:       "bad ttl",
+			method:     http.MethodPut,
+			path:       "/admin/loggers/db",
+			body:       `{"level":"debug","ttl":"forever"}`,
+			wantStatus: http.StatusBadRequest,
+			wantLevel:  "warn",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			w := httptest.NewRecorder()
+
+			e.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Result().StatusCode)
+
+			ll, ok := r.Level("db")
+			require.True(t, ok)
+			assert.Equal(t, tt.wantLevel, ll.Level)
+		})
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/loggers", nil)
+	w := httptest.NewRecorder()
+
+	e.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Result().StatusCode)
+
+	var levels []LoggerLevel
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&levels))
+	require.Len(t, levels, 1)
+	assert.Equal(t, "db", levels[0].Name)
+	assert.Equal(t, "warn", levels[0].Level)
+	assert.NotNil(t, levels[0].RevertAt)
+}
@@ -0,0 +1,147 @@
+package observability
+
+import (
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	honeycombTeamHeader    = "x-honeycomb-team"
+	honeycombDatasetHeader = "x-honeycomb-dataset"
+)
+
+var (
+	// classicKeyPattern matches the configuration keys of Honeycomb Classic teams, 32 lower case hex characters.
+	classicKeyPattern = regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+	// classicIngestKeyPattern matches the ingest keys of Honeycomb Classic teams, like hcaic_ followed by 58 lower case
+	// letters and digits. It's the same pattern libhoney uses.
+	classicIngestKeyPattern = regexp.MustCompile(`^hc[a-z]ic_[0-9a-z]{58}$`)
+)
+
+// HoneycombCredentials holds everything that's needed to ship data to Honeycomb. It's shared between the tracer and the
+// meter, so both of them authenticate the same way.
+//
+// Exactly one of APIKey, APIKeyFile, or APIKeyEnv needs to be set:
+//   - APIKey is the key itself, useful for local development
+//   - APIKeyFile is a path to a file that holds the key, for example a mounted kubernetes secret. Surrounding
+//     whitespace is trimmed
+//   - APIKeyEnv is the name of an environment variable that holds the key
+//
+// Whether the Dataset is required depends on the kind of team the key belongs to. Honeycomb Classic teams need a
+// dataset, environment based teams derive the dataset for traces from the service name, so the dataset header is not
+// sent for traces. Metrics always need a dataset: MetricsDataset is used when set, otherwise Dataset is used.
+type HoneycombCredentials struct {
+	APIKey         string
+	APIKeyFile     string
+	APIKeyEnv      string
+	Dataset        string
+	MetricsDataset string
+}
+
+// Classic reports whether the API key belongs to a Honeycomb Classic team. Classic configuration keys are 32 lower case
+// hex characters long, and classic ingest keys are an "hc?ic_" prefix, like "hcaic_", followed by 58 lower case letters
+// and digits. Everything else belongs to an environment.
+func (h HoneycombCredentials) Classic() (bool, error) {
+	key, err := h.apiKey()
+	if err != nil {
+		return false, errors.Wrap(err, "h.apiKey")
+	}
+
+	return isClassicKey(key), nil
+}
+
+// traceHeaders returns the grpc headers to use when exporting traces to Honeycomb.
+func (h HoneycombCredentials) traceHeaders() (map[string]string, error) {
+	key, err := h.apiKey()
+	if err != nil {
+		return nil, errors.Wrap(err, "h.apiKey")
+	}
+
+	headers := map[string]string{
+		honeycombTeamHeader: key,
+	}
+
+	if !isClassicKey(key) {
+		return headers, nil
+	}
+
+	if h.Dataset == "" {
+		return nil, errors.New("dataset is required when using a Honeycomb Classic API key")
+	}
+
+	headers[honeycombDatasetHeader] = h.Dataset
+
+	return headers, nil
+}
+
+// metricsHeaders returns the grpc headers to use when exporting metrics to Honeycomb.
+func (h HoneycombCredentials) metricsHeaders() (map[string]string, error) {
+	key, err := h.apiKey()
+	if err != nil {
+		return nil, errors.Wrap(err, "h.apiKey")
+	}
+
+	dataset := h.MetricsDataset
+	if dataset == "" {
+		dataset = h.Dataset
+	}
+
+	if dataset == "" {
+		return nil, errors.New("metrics dataset is required to send metrics to Honeycomb, set either MetricsDataset or" +
+			" Dataset")
+	}
+
+	return map[string]string{
+		honeycombTeamHeader:    key,
+		honeycombDatasetHeader: dataset,
+	}, nil
+}
+
+// apiKey resolves the API key from whichever one of the three sources was configured.
+func (h HoneycombCredentials) apiKey() (string, error) {
+	sources := 0
+	for _, s := range []string{h.APIKey, h.APIKeyFile, h.APIKeyEnv} {
+		if s != "" {
+			sources++
+		}
+	}
+
+	if sources != 1 {
+		return "", errors.New("exactly one of APIKey, APIKeyFile, or APIKeyEnv needs to be set")
+	}
+
+	key := h.APIKey
+
+	switch {
+	case h.APIKeyFile != "":
+		b, err := os.ReadFile(h.APIKeyFile)
+		if err != nil {
+			return "", errors.Wrap(err, "os.ReadFile")
+		}
+
+		key = string(b)
+	case h.APIKeyEnv != "":
+		v, ok := os.LookupEnv(h.APIKeyEnv)
+		if !ok {
+			return "", errors.Errorf("environment variable %s is not set", h.APIKeyEnv)
+		}
+
+		key = v
+	}
+
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return "", errors.New("honeycomb API key is empty")
+	}
+
+	return key, nil
+}
+
+// isClassicKey reports whether the passed in key belongs to a Honeycomb Classic team.
+func isClassicKey(key string) bool {
+	return classicKeyPattern.MatchString(key) || classicIngestKeyPattern.MatchString(key)
+}
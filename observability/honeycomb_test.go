@@ -0,0 +1,258 @@
+package observability
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHoneycombCredentials_traceHeaders(t *testing.T) {
+	const (
+		classicKey       = "0123456789abcdef0123456789abcdef"
+		classicIngestKey = "hcaic_0123456789abcdefghijklmnopqrstuvwxyz0123456789abcdefghijkl"
+		environmentKey   = "aBcDeFgHiJkLmNoPqRsTuV"
+	)
+
+	keyFile := filepath.Join(t.TempDir(), "apikey")
+	require.NoError(t, os.WriteFile(keyFile, []byte(environmentKey+"\n"), 0600))
+
+	t.Setenv("TEST_HONEYCOMB_KEY", classicKey)
+
+	tests := []struct {
+		name        string
+		credentials HoneycombCredentials
+		want        map[string]string
+		wantErr     bool
+	}{
+		{
+			name: "classic key with dataset",
+			credentials: HoneycombCredentials{
+				APIKey:  classicKey,
+				Dataset: "traces",
+			},
+			want: map[string]string{
+				honeycombTeamHeader:    classicKey,
+				honeycombDatasetHeader: "traces",
+			},
+		},
+		{
+			name: "classic key without dataset",
+			credentials: HoneycombCredentials{
+				APIKey: classicKey,
+			},
+			wantErr: true,
+		},
+		{
+			name: "classic ingest key with dataset",
+			credentials: HoneycombCredentials{
+				APIKey:  classicIngestKey,
+				Dataset: "traces",
+			},
+			want: map[string]string{
+				honeycombTeamHeader:    classicIngestKey,
+				honeycombDatasetHeader: "traces",
+			},
+		},
+		{
+			name: "environment key without dataset",
+			credentials: HoneycombCredentials{
+				APIKey: environmentKey,
+			},
+			want: map[string]string{
+				honeycombTeamHeader: environmentKey,
+			},
+		},
+		{
+			name: "environment key with dataset does not send dataset",
+			credentials: HoneycombCredentials{
+				APIKey:  environmentKey,
+				Dataset: "traces",
+			},
+			want: map[string]string{
+				honeycombTeamHeader: environmentKey,
+			},
+		},
+		{
+			name: "key from file is trimmed",
+			credentials: HoneycombCredentials{
+				APIKeyFile: keyFile,
+			},
+			want: map[string]string{
+				honeycombTeamHeader: environmentKey,
+			},
+		},
+		{
+			name: "key from env",
+			credentials: HoneycombCredentials{
+				APIKeyEnv: "TEST_HONEYCOMB_KEY",
+				Dataset:   "traces",
+			},
+			want: map[string]string{
+				honeycombTeamHeader:    classicKey,
+				honeycombDatasetHeader: "traces",
+			},
+		},
+		{
+			name: "key from unset env",
+			credentials: HoneycombCredentials{
+				APIKeyEnv: "TEST_HONEYCOMB_KEY_NOT_SET",
+			},
+			wantErr: true,
+		},
+		{
+			name: "key from missing file",
+			credentials: HoneycombCredentials{
+				APIKeyFile: filepath.Join(t.TempDir(), "nope"),
+			},
+			wantErr: true,
+		},
+		{
+			name:        "no key",
+			credentials: HoneycombCredentials{},
+			wantErr:     true,
+		},
+		{
+			name: "more than one key source",
+			credentials: HoneycombCredentials{
+				APIKey:    environmentKey,
+				APIKeyEnv: "TEST_HONEYCOMB_KEY",
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.credentials.traceHeaders()
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestHoneycombCredentials_metricsHeaders(t *testing.T) {
+	const environmentKey = "aBcDeFgHiJkLmNoPqRsTuV"
+
+	tests := []struct {
+		name        string
+		credentials HoneycombCredentials
+		want        map[string]string
+		wantErr     bool
+	}{
+		{
+			name: "metrics dataset takes precedence",
+			credentials: HoneycombCredentials{
+				APIKey:         environmentKey,
+				Dataset:        "traces",
+				MetricsDataset: "metrics",
+			},
+			want: map[string]string{
+				honeycombTeamHeader:    environmentKey,
+				honeycombDatasetHeader: "metrics",
+			},
+		},
+		{
+			name: "falls back to dataset",
+			credentials: HoneycombCredentials{
+				APIKey:  environmentKey,
+				Dataset: "traces",
+			},
+			want: map[string]string{
+				honeycombTeamHeader:    environmentKey,
+				honeycombDatasetHeader: "traces",
+			},
+		},
+		{
+			name: "no dataset at all",
+			credentials: HoneycombCredentials{
+				APIKey: environmentKey,
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.credentials.metricsHeaders()
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestIsClassicKey(t *testing.T) {
+	tests := []struct {
+		name string
+		key  string
+		want bool
+	}{
+		{
+			name: "classic configuration key",
+			key:  "0123456789abcdef0123456789abcdef",
+			want: true,
+		},
+		{
+			name: "configuration key with upper case hex",
+			key:  "0123456789ABCDEF0123456789ABCDEF",
+		},
+		{
+			name: "classic ingest key",
+			key:  "hcaic_0123456789abcdefghijklmnopqrstuvwxyz0123456789abcdefghijkl",
+			want: true,
+		},
+		{
+			name: "classic ingest key with another prefix",
+			key:  "hcxic_0123456789abcdefghijklmnopqrstuvwxyz0123456789abcdefghijkl",
+			want: true,
+		},
+		{
+			name: "short classic ingest key",
+			key:  "hcaic_0123456789abcdefghijklmnopqrstuvwxyz",
+		},
+		{
+			name: "environment ingest key",
+			key:  "hcxik_0123456789abcdefghijklmnopqrstuvwxyz0123456789abcdefghijkl",
+		},
+		{
+			name: "environment configuration key",
+			key:  "aBcDeFgHiJkLmNoPqRsTuV",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, isClassicKey(tt.key))
+		})
+	}
+}
+
+func TestHoneycombTracingConfig_Credentials(t *testing.T) {
+	config := HoneycombTracingConfig{
+		HoneycombCredentials: HoneycombCredentials{
+			APIKeyEnv:      "TEST_HONEYCOMB_KEY",
+			Dataset:        "traces",
+			MetricsDataset: "metrics",
+		},
+	}
+
+	assert.Equal(t, config.HoneycombCredentials, config.Credentials())
+
+	config.APIKey = "aBcDeFgHiJkLmNoPqRsTuV"
+	config.Dataset = "deprecated"
+
+	assert.Equal(t, HoneycombCredentials{
+		APIKey:         "aBcDeFgHiJkLmNoPqRsTuV",
+		Dataset:        "deprecated",
+		MetricsDataset: "metrics",
+	}, config.Credentials())
+}
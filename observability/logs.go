@@ -0,0 +1,452 @@
+package observability
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/resource"
+	"go.opentelemetry.io/otel/trace"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	"google.golang.org/grpc"
+)
+
+const (
+	defaultLogQueueSize      = 2048
+	defaultLogBatchSize      = 512
+	defaultLogExportInterval = time.Second
+	defaultLogExportTimeout  = 5 * time.Second
+
+	logScopeName = "github.com/suborbital/go-kit/observability"
+)
+
+// LogConfig holds the configuration for the OTLP log writer. The service fields should hold the same values as the
+// MeterConfig, so logs and metrics are attributed to the same service.
+//
+// QueueSize is the number of log lines that can wait to be exported. When the queue is full, because the collector is
+// slow or unavailable, new log lines are dropped instead of blocking the caller. BatchSize is the maximum number of log
+// lines sent in one export, and ExportInterval is how often a partial batch is sent. ExportTimeout bounds each export.
+// Zero values are replaced with defaults.
+type LogConfig struct {
+	ServiceName      string
+	ServiceNamespace string
+	ServiceVersion   string
+	QueueSize        int
+	BatchSize        int
+	ExportInterval   time.Duration
+	ExportTimeout    time.Duration
+}
+
+// LogWriter is a zerolog.LevelWriter that turns each log event into an OTLP log record, and ships them in batches to
+// an opentelemetry collector. Writes never block on the collector: they are queued, and dropped if the queue is full.
+//
+// Create one with OtelLogWriter, and call Shutdown before the application exits to flush the queue.
+type LogWriter struct {
+	client   collogspb.LogsServiceClient
+	resource *resourcepb.Resource
+	config   LogConfig
+
+	queue    chan queuedLog
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+	dropped  atomic.Uint64
+
+	// mu makes checking stop and queueing a line in WriteLevel one step, so no line is queued after run drained the
+	// queue for the last time. Writes share it, Shutdown holds it to close stop.
+	mu sync.RWMutex
+}
+
+// queuedLog is a log line waiting to be exported.
+type queuedLog struct {
+	level    zerolog.Level
+	observed time.Time
+	data     []byte
+}
+
+// OtelLogWriter takes a grpc connection to an otel collector and a LogConfig, and returns a LogWriter that can be
+// passed to zerolog, either on its own, or together with other writers through zerolog.MultiLevelWriter:
+//
+//	lw, err := observability.OtelLogWriter(grpcConn, lc)
+//	logger := zerolog.New(zerolog.MultiLevelWriter(os.Stderr, lw))
+//
+// If the logger has the TraceHook, the trace fields of the log lines are turned into the trace context of the log
+// records.
+func OtelLogWriter(conn *grpc.ClientConn, config LogConfig) (*LogWriter, error) {
+	if config.QueueSize <= 0 {
+		config.QueueSize = defaultLogQueueSize
+	}
+
+	if config.BatchSize <= 0 {
+		config.BatchSize = defaultLogBatchSize
+	}
+
+	if config.ExportInterval <= 0 {
+		config.ExportInterval = defaultLogExportInterval
+	}
+
+	if config.ExportTimeout <= 0 {
+		config.ExportTimeout = defaultLogExportTimeout
+	}
+
+	r, err := serviceResource(config.ServiceName, config.ServiceNamespace, config.ServiceVersion,
+		attribute.String("exporter", "grpc"),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "serviceResource")
+	}
+
+	w := &LogWriter{
+		client:   collogspb.NewLogsServiceClient(conn),
+		resource: resourceToProto(r),
+		config:   config,
+		queue:    make(chan queuedLog, config.QueueSize),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+
+	go w.run()
+
+	return w, nil
+}
+
+// Write implements io.Writer. The level of the log line is read from the event itself.
+func (w *LogWriter) Write(p []byte) (int, error) {
+	return w.WriteLevel(zerolog.NoLevel, p)
+}
+
+// WriteLevel implements zerolog.LevelWriter. The log line is copied and queued, so it's safe for zerolog to reuse the
+// buffer once this returns. It never returns an error: if the queue is full, or the writer has been shut down, the line
+// is dropped and counted.
+func (w *LogWriter) WriteLevel(level zerolog.Level, p []byte) (int, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	select {
+	case <-w.stop:
+		w.dropped.Add(1)
+		return len(p), nil
+	default:
+	}
+
+	ql := queuedLog{
+		level:    level,
+		observed: time.Now(),
+		data:     bytes.Clone(p),
+	}
+
+	select {
+	case w.queue <- ql:
+	default:
+		w.dropped.Add(1)
+	}
+
+	return len(p), nil
+}
+
+// Dropped returns the number of log lines that were not exported, either because the queue was full, the export
+// failed, or they were written after Shutdown.
+func (w *LogWriter) Dropped() uint64 {
+	return w.dropped.Load()
+}
+
+// Shutdown stops accepting new log lines, and exports the ones still in the queue. It returns when either everything is
+// exported, or the context is done, whichever comes first.
+func (w *LogWriter) Shutdown(ctx context.Context) error {
+	w.stopOnce.Do(func() {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+
+		close(w.stop)
+	})
+
+	select {
+	case <-w.done:
+		return nil
+	case <-ctx.Done():
+		return errors.Wrap(ctx.Err(), "waiting for the log queue to flush")
+	}
+}
+
+// run collects the queued log lines into batches, and exports a batch whenever it's full, or when the export interval
+// ticks. It exits after a final flush once the writer is shut down.
+func (w *LogWriter) run() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.config.ExportInterval)
+	defer ticker.Stop()
+
+	batch := make([]*logspb.LogRecord, 0, w.config.BatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+
+		w.export(batch)
+		batch = make([]*logspb.LogRecord, 0, w.config.BatchSize)
+	}
+
+	add := func(ql queuedLog) {
+		batch = append(batch, toLogRecord(ql))
+		if len(batch) >= w.config.BatchSize {
+			flush()
+		}
+	}
+
+	for {
+		select {
+		case ql := <-w.queue:
+			add(ql)
+		case <-ticker.C:
+			flush()
+		case <-w.stop:
+			for {
+				select {
+				case ql := <-w.queue:
+					add(ql)
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+// export sends one batch of log records to the collector. Failed batches are dropped, there's no retry beyond what the
+// grpc connection does on its own.
+func (w *LogWriter) export(records []*logspb.LogRecord) {
+	ctx, cancel := context.WithTimeout(context.Background(), w.config.ExportTimeout)
+	defer cancel()
+
+	_, err := w.client.Export(ctx, &collogspb.ExportLogsServiceRequest{
+		ResourceLogs: []*logspb.ResourceLogs{
+			{
+				Resource: w.resource,
+				ScopeLogs: []*logspb.ScopeLogs{
+					{
+						Scope:      &commonpb.InstrumentationScope{Name: logScopeName},
+						LogRecords: records,
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		w.dropped.Add(uint64(len(records)))
+	}
+}
+
+// toLogRecord parses a zerolog JSON event into an OTLP log record. The level, timestamp, message and trace fields are
+// mapped onto their dedicated fields of the record, everything else becomes an attribute. Lines that aren't valid JSON
+// are sent as they are in the body of the record.
+func toLogRecord(ql queuedLog) *logspb.LogRecord {
+	record := &logspb.LogRecord{
+		TimeUnixNano:         uint64(ql.observed.UnixNano()),
+		ObservedTimeUnixNano: uint64(ql.observed.UnixNano()),
+	}
+
+	level := ql.level
+
+	fields := make(map[string]interface{})
+	d := json.NewDecoder(bytes.NewReader(ql.data))
+	d.UseNumber()
+
+	if err := d.Decode(&fields); err != nil {
+		record.Body = stringValue(string(bytes.TrimSpace(ql.data)))
+		record.SeverityNumber, record.SeverityText = severity(level)
+
+		return record
+	}
+
+	for k, v := range fields {
+		switch k {
+		case zerolog.LevelFieldName:
+			s, ok := v.(string)
+			if !ok {
+				break
+			}
+
+			if l, err := zerolog.ParseLevel(s); err == nil && level == zerolog.NoLevel {
+				level = l
+			}
+
+			continue
+		case zerolog.TimestampFieldName:
+			if t, ok := parseTimestamp(v); ok {
+				record.TimeUnixNano = uint64(t.UnixNano())
+				continue
+			}
+		case zerolog.MessageFieldName:
+			if s, ok := v.(string); ok {
+				record.Body = stringValue(s)
+				continue
+			}
+		case TraceIDFieldName:
+			if id, err := trace.TraceIDFromHex(toString(v)); err == nil {
+				record.TraceId = id[:]
+				continue
+			}
+		case SpanIDFieldName:
+			if id, err := trace.SpanIDFromHex(toString(v)); err == nil {
+				record.SpanId = id[:]
+				continue
+			}
+		case TraceFlagsFieldName:
+			if b, err := hex.DecodeString(toString(v)); err == nil && len(b) == 1 {
+				record.Flags = uint32(b[0])
+				continue
+			}
+		}
+
+		record.Attributes = append(record.Attributes, &commonpb.KeyValue{Key: k, Value: anyValue(v)})
+	}
+
+	record.SeverityNumber, record.SeverityText = severity(level)
+
+	return record
+}
+
+// severity maps zerolog's levels to OTLP severity numbers and texts.
+func severity(level zerolog.Level) (logspb.SeverityNumber, string) {
+	switch level {
+	case zerolog.TraceLevel:
+		return logspb.SeverityNumber_SEVERITY_NUMBER_TRACE, "TRACE"
+	case zerolog.DebugLevel:
+		return logspb.SeverityNumber_SEVERITY_NUMBER_DEBUG, "DEBUG"
+	case zerolog.InfoLevel:
+		return logspb.SeverityNumber_SEVERITY_NUMBER_INFO, "INFO"
+	case zerolog.WarnLevel:
+		return logspb.SeverityNumber_SEVERITY_NUMBER_WARN, "WARN"
+	case zerolog.ErrorLevel:
+		return logspb.SeverityNumber_SEVERITY_NUMBER_ERROR, "ERROR"
+	case zerolog.FatalLevel:
+		return logspb.SeverityNumber_SEVERITY_NUMBER_FATAL, "FATAL"
+	case zerolog.PanicLevel:
+		return logspb.SeverityNumber_SEVERITY_NUMBER_FATAL4, "PANIC"
+	default:
+		return logspb.SeverityNumber_SEVERITY_NUMBER_UNSPECIFIED, ""
+	}
+}
+
+// parseTimestamp parses the timestamp field of an event according to zerolog's configured time format, or as RFC3339
+// with nanoseconds, which is what the loggers of the log package use.
+func parseTimestamp(v interface{}) (time.Time, bool) {
+	switch tv := v.(type) {
+	case string:
+		t, err := time.Parse(zerolog.TimeFieldFormat, tv)
+		if err != nil {
+			t, err = time.Parse(time.RFC3339Nano, tv)
+		}
+
+		if err != nil {
+			return time.Time{}, false
+		}
+
+		return t, true
+	case json.Number:
+		n, err := tv.Int64()
+		if err != nil {
+			return time.Time{}, false
+		}
+
+		switch zerolog.TimeFieldFormat {
+		case zerolog.TimeFormatUnix:
+			return time.Unix(n, 0), true
+		case zerolog.TimeFormatUnixMs:
+			return time.UnixMilli(n), true
+		case zerolog.TimeFormatUnixMicro:
+			return time.UnixMicro(n), true
+		case zerolog.TimeFormatUnixNano:
+			return time.Unix(0, n), true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// anyValue converts a value decoded from JSON into an OTLP AnyValue.
+func anyValue(v interface{}) *commonpb.AnyValue {
+	switch tv := v.(type) {
+	case string:
+		return stringValue(tv)
+	case bool:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_BoolValue{BoolValue: tv}}
+	case json.Number:
+		if i, err := tv.Int64(); err == nil {
+			return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: i}}
+		}
+
+		f, err := tv.Float64()
+		if err != nil {
+			return stringValue(tv.String())
+		}
+
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_DoubleValue{DoubleValue: f}}
+	case []interface{}:
+		values := make([]*commonpb.AnyValue, 0, len(tv))
+		for _, e := range tv {
+			values = append(values, anyValue(e))
+		}
+
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_ArrayValue{ArrayValue: &commonpb.ArrayValue{Values: values}}}
+	case map[string]interface{}:
+		kvs := make([]*commonpb.KeyValue, 0, len(tv))
+		for k, e := range tv {
+			kvs = append(kvs, &commonpb.KeyValue{Key: k, Value: anyValue(e)})
+		}
+
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_KvlistValue{KvlistValue: &commonpb.KeyValueList{Values: kvs}}}
+	default:
+		return &commonpb.AnyValue{}
+	}
+}
+
+// stringValue wraps a string into an OTLP AnyValue.
+func stringValue(s string) *commonpb.AnyValue {
+	return &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: s}}
+}
+
+// toString returns v if it's a string, or an empty string otherwise.
+func toString(v interface{}) string {
+	s, _ := v.(string)
+
+	return s
+}
+
+// resourceToProto converts the sdk resource into its OTLP representation.
+func resourceToProto(r *resource.Resource) *resourcepb.Resource {
+	attrs := make([]*commonpb.KeyValue, 0, r.Len())
+
+	iter := r.Iter()
+	for iter.Next() {
+		kv := iter.Attribute()
+		attrs = append(attrs, &commonpb.KeyValue{Key: string(kv.Key), Value: attributeValue(kv.Value)})
+	}
+
+	return &resourcepb.Resource{Attributes: attrs}
+}
+
+// attributeValue converts an attribute value into an OTLP AnyValue.
+func attributeValue(v attribute.Value) *commonpb.AnyValue {
+	switch v.Type() {
+	case attribute.BOOL:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_BoolValue{BoolValue: v.AsBool()}}
+	case attribute.INT64:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: v.AsInt64()}}
+	case attribute.FLOAT64:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_DoubleValue{DoubleValue: v.AsFloat64()}}
+	default:
+		return stringValue(v.Emit())
+	}
+}
@@ -0,0 +1,188 @@
+package observability
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/sdk/trace"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// mockLogsService is an in memory collector that keeps every log record it receives.
+type mockLogsService struct {
+	collogspb.UnimplementedLogsServiceServer
+
+	mu      sync.Mutex
+	records []*logspb.LogRecord
+}
+
+func (m *mockLogsService) Export(_ context.Context, req *collogspb.ExportLogsServiceRequest) (*collogspb.ExportLogsServiceResponse, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, rl := range req.GetResourceLogs() {
+		for _, sl := range rl.GetScopeLogs() {
+			m.records = append(m.records, sl.GetLogRecords()...)
+		}
+	}
+
+	return &collogspb.ExportLogsServiceResponse{}, nil
+}
+
+func (m *mockLogsService) received() []*logspb.LogRecord {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]*logspb.LogRecord(nil), m.records...)
+}
+
+func mockCollector(t *testing.T) (*mockLogsService, *grpc.ClientConn) {
+	t.Helper()
+
+	lis := bufconn.Listen(1024 * 1024)
+	svc := &mockLogsService{}
+
+	srv := grpc.NewServer()
+	collogspb.RegisterLogsServiceServer(srv, svc)
+
+	go func() {
+		_ = srv.Serve(lis)
+	}()
+
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		_ = conn.Close()
+	})
+
+	return svc, conn
+}
+
+func TestLogWriter(t *testing.T) {
+	svc, conn := mockCollector(t)
+
+	lw, err := OtelLogWriter(conn, LogConfig{
+		ServiceName:    "test-service",
+		ExportInterval: time.Hour,
+	})
+	require.NoError(t, err)
+
+	tp := trace.NewTracerProvider(trace.WithSampler(trace.AlwaysSample()))
+	ctx, span := tp.Tracer("test").Start(context.Background(), "span")
+	defer span.End()
+
+	l := zerolog.New(lw).Hook(TraceHook{})
+	l.Warn().Ctx(ctx).Str("requestID", "abc").Int("count", 3).Msg("first")
+	l.Error().Bool("retry", false).Msg("second")
+
+	require.NoError(t, lw.Shutdown(context.Background()))
+
+	records := svc.received()
+	require.Len(t, records, 2)
+
+	first := records[0]
+	assert.Equal(t, "first", first.GetBody().GetStringValue())
+	assert.Equal(t, logspb.SeverityNumber_SEVERITY_NUMBER_WARN, first.GetSeverityNumber())
+	assert.Equal(t, "WARN", first.GetSeverityText())
+
+	traceID := span.SpanContext().TraceID()
+	spanID := span.SpanContext().SpanID()
+	assert.Equal(t, traceID[:], first.GetTraceId())
+	assert.Equal(t, spanID[:], first.GetSpanId())
+	assert.Equal(t, uint32(1), first.GetFlags())
+
+	attrs := make(map[string]interface{})
+	for _, kv := range first.GetAttributes() {
+		switch {
+		case kv.GetValue().GetStringValue() != "":
+			attrs[kv.GetKey()] = kv.GetValue().GetStringValue()
+		default:
+			attrs[kv.GetKey()] = kv.GetValue().GetIntValue()
+		}
+	}
+
+	assert.Equal(t, map[string]interface{}{"requestID": "abc", "count": int64(3)}, attrs)
+
+	second := records[1]
+	assert.Equal(t, "second", second.GetBody().GetStringValue())
+	assert.Equal(t, logspb.SeverityNumber_SEVERITY_NUMBER_ERROR, second.GetSeverityNumber())
+	assert.Empty(t, second.GetTraceId())
+}
+
+func TestLogWriter_dropsWhenFull(t *testing.T) {
+	_, conn := mockCollector(t)
+
+	lw, err := OtelLogWriter(conn, LogConfig{
+		QueueSize:      1,
+		BatchSize:      1000,
+		ExportInterval: time.Hour,
+	})
+	require.NoError(t, err)
+
+	l := zerolog.New(lw)
+
+	// None of these should block, regardless of how slowly the queue is drained.
+	for i := 0; i < 1000; i++ {
+		l.Info().Msg("flood")
+	}
+
+	require.NoError(t, lw.Shutdown(context.Background()))
+
+	dropped := lw.Dropped()
+	assert.NotZero(t, dropped)
+
+	l.Info().Msg("after shutdown")
+	assert.Equal(t, dropped+1, lw.Dropped())
+}
+
+func TestLogWriter_shutdownWhileWriting(t *testing.T) {
+	svc, conn := mockCollector(t)
+
+	lw, err := OtelLogWriter(conn, LogConfig{
+		QueueSize:      10000,
+		ExportInterval: time.Hour,
+	})
+	require.NoError(t, err)
+
+	l := zerolog.New(lw)
+
+	const writers, lines = 8, 500
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for j := 0; j < lines; j++ {
+				l.Info().Msg("racing")
+			}
+		}()
+	}
+
+	require.NoError(t, lw.Shutdown(context.Background()))
+	wg.Wait()
+
+	// every line is either exported, or counted as dropped, none is left behind in the queue.
+	assert.Equal(t, uint64(writers*lines), uint64(len(svc.received()))+lw.Dropped())
+}
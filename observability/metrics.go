@@ -9,8 +9,7 @@ import (
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
 	"go.opentelemetry.io/otel/sdk/metric"
-	"go.opentelemetry.io/otel/sdk/resource"
-	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
 	"google.golang.org/grpc"
 )
 
@@ -23,6 +22,10 @@ type MeterConfig struct {
 	ServiceName      string
 	ServiceNamespace string
 	ServiceVersion   string
+
+	// Honeycomb is optional. When set, the metrics are sent with the Honeycomb credentials attached, so they can be
+	// shipped to Honeycomb either directly, or through a collector that forwards the headers.
+	Honeycomb *HoneycombCredentials
 }
 
 // OtelMeter takes a grpc connection to an otel collector, a MeterConfig that holds important data like collection
@@ -37,11 +40,21 @@ func OtelMeter(ctx context.Context, conn *grpc.ClientConn, meterConfig MeterConf
 			" overloading the collector")
 	}
 
-	// exporter is the thing that will send the data from the app to wherever else it needs to go.
-	exporter, err := otlpmetricgrpc.New(
-		ctx,
+	exporterOpts := []otlpmetricgrpc.Option{
 		otlpmetricgrpc.WithGRPCConn(conn),
-	)
+	}
+
+	if meterConfig.Honeycomb != nil {
+		headers, err := meterConfig.Honeycomb.metricsHeaders()
+		if err != nil {
+			return nil, errors.Wrap(err, "meterConfig.Honeycomb.metricsHeaders")
+		}
+
+		exporterOpts = append(exporterOpts, otlpmetricgrpc.WithHeaders(headers))
+	}
+
+	// exporter is the thing that will send the data from the app to wherever else it needs to go.
+	exporter, err := otlpmetricgrpc.New(ctx, exporterOpts...)
 	if err != nil {
 		return nil, errors.Wrap(err, "otlpmetricgrpc.New")
 	}
@@ -54,16 +67,16 @@ func OtelMeter(ctx context.Context, conn *grpc.ClientConn, meterConfig MeterConf
 	)
 
 	// resource configures the very basic attributes of every measurement taken.
-	r, err := resource.Merge(
-		resource.Default(),
-		resource.NewWithAttributes(
-			semconv.SchemaURL,
-			semconv.ServiceNameKey.String(meterConfig.ServiceName),
-			semconv.ServiceNamespaceKey.String(meterConfig.ServiceNamespace),
-			semconv.ServiceVersionKey.String(meterConfig.ServiceVersion),
-			semconv.DBSystemPostgreSQL,
-			attribute.String("exporter", "grpc"),
-		))
+	r, err := serviceResource(
+		meterConfig.ServiceName,
+		meterConfig.ServiceNamespace,
+		meterConfig.ServiceVersion,
+		semconv.DBSystemPostgreSQL,
+		attribute.String("exporter", "grpc"),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "serviceResource")
+	}
 
 	// meterProvider takes the resource, and the reader, to provide a thing that we can create actual instruments out
 	// of, so we can start measuring things.
@@ -0,0 +1,29 @@
+package observability
+
+import (
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+)
+
+// serviceResource returns the resource that describes the service for metrics and logs, so both of them are attributed
+// to the same service. The default resource is merged in, which is why the semconv version needs to match the one the
+// sdk uses, otherwise the merge fails on conflicting schema URLs.
+func serviceResource(serviceName, serviceNamespace, serviceVersion string, attrs ...attribute.KeyValue) (*resource.Resource, error) {
+	attrs = append([]attribute.KeyValue{
+		semconv.ServiceName(serviceName),
+		semconv.ServiceNamespace(serviceNamespace),
+		semconv.ServiceVersion(serviceVersion),
+	}, attrs...)
+
+	r, err := resource.Merge(
+		resource.Default(),
+		resource.NewWithAttributes(semconv.SchemaURL, attrs...),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "resource.Merge")
+	}
+
+	return r, nil
+}
@@ -0,0 +1,54 @@
+package observability
+
+import (
+	"context"
+
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Field names used to add the trace context of the active span to log lines. They are variables so services can change
+// them to match whatever their log backend expects, similarly to how zerolog's own field names can be changed. Change
+// them once on startup before any logging happens.
+var (
+	TraceIDFieldName    = "trace_id"
+	SpanIDFieldName     = "span_id"
+	TraceFlagsFieldName = "trace_flags"
+)
+
+// TraceHook is a zerolog.Hook that adds the trace ID, span ID, and trace flags of the span that's active in the event's
+// context to the log line. The context needs to be passed to the event with zerolog's Ctx method, for example:
+//
+//	l := logger.Hook(observability.TraceHook{})
+//	l.Info().Ctx(c.Request().Context()).Msg("something happened")
+//
+// If there's no valid span in the context, the event is left untouched.
+type TraceHook struct{}
+
+// Run implements zerolog.Hook.
+func (TraceHook) Run(e *zerolog.Event, _ zerolog.Level, _ string) {
+	sc := trace.SpanContextFromContext(e.GetCtx())
+	if !sc.IsValid() {
+		return
+	}
+
+	e.Str(TraceIDFieldName, sc.TraceID().String()).
+		Str(SpanIDFieldName, sc.SpanID().String()).
+		Str(TraceFlagsFieldName, sc.TraceFlags().String())
+}
+
+// TraceLogger returns a child logger of the passed in one that has the trace ID, span ID, and trace flags of the span
+// that's active in ctx added to it as fields. Useful when the logger is handed to code that does not have access to the
+// context. If there's no valid span in the context, the logger is returned as is.
+func TraceLogger(ctx context.Context, l zerolog.Logger) zerolog.Logger {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return l
+	}
+
+	return l.With().
+		Str(TraceIDFieldName, sc.TraceID().String()).
+		Str(SpanIDFieldName, sc.SpanID().String()).
+		Str(TraceFlagsFieldName, sc.TraceFlags().String()).
+		Logger()
+}
@@ -0,0 +1,78 @@
+package observability
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/sdk/trace"
+)
+
+func TestTraceHook(t *testing.T) {
+	tp := trace.NewTracerProvider(trace.WithSampler(trace.AlwaysSample()))
+	spanCtx, span := tp.Tracer("test").Start(context.Background(), "span")
+	defer span.End()
+
+	sc := span.SpanContext()
+
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		wantTrace bool
+	}{
+		{
+			name:      "context with span",
+			ctx:       spanCtx,
+			wantTrace: true,
+		},
+		{
+			name:      "context without span",
+			ctx:       context.Background(),
+			wantTrace: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := bytes.NewBuffer(nil)
+			l := zerolog.New(b).Hook(TraceHook{})
+
+			l.Info().Ctx(tt.ctx).Msg("hello")
+
+			fields := make(map[string]interface{})
+			require.NoError(t, json.Unmarshal(b.Bytes(), &fields))
+
+			if !tt.wantTrace {
+				assert.NotContains(t, fields, TraceIDFieldName)
+				assert.NotContains(t, fields, SpanIDFieldName)
+				assert.NotContains(t, fields, TraceFlagsFieldName)
+
+				return
+			}
+
+			assert.Equal(t, sc.TraceID().String(), fields[TraceIDFieldName])
+			assert.Equal(t, sc.SpanID().String(), fields[SpanIDFieldName])
+			assert.Equal(t, "01", fields[TraceFlagsFieldName])
+		})
+	}
+}
+
+func TestTraceLogger(t *testing.T) {
+	tp := trace.NewTracerProvider(trace.WithSampler(trace.AlwaysSample()))
+	ctx, span := tp.Tracer("test").Start(context.Background(), "span")
+	defer span.End()
+
+	b := bytes.NewBuffer(nil)
+	l := TraceLogger(ctx, zerolog.New(b))
+
+	l.Info().Msg("hello")
+
+	fields := make(map[string]interface{})
+	require.NoError(t, json.Unmarshal(b.Bytes(), &fields))
+
+	assert.Equal(t, span.SpanContext().TraceID().String(), fields[TraceIDFieldName])
+	assert.Equal(t, span.SpanContext().SpanID().String(), fields[SpanIDFieldName])
+}
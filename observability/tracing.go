@@ -27,13 +27,39 @@ type TracingConfig struct {
 	ServiceName string
 }
 
-// HoneycombTracingConfig embeds the TracingConfig struct, and adds other, specifically Honeycomb related fields.
+// HoneycombTracingConfig embeds the TracingConfig struct, and the HoneycombCredentials, which hold the Honeycomb
+// specific fields.
 type HoneycombTracingConfig struct {
 	TracingConfig
-	APIKey  string
+	HoneycombCredentials
+
+	// Deprecated: set APIKey on the HoneycombCredentials instead. When set, it's used in place of the API key of the
+	// HoneycombCredentials, including APIKeyFile and APIKeyEnv.
+	APIKey string
+
+	// Deprecated: set Dataset on the HoneycombCredentials instead. When set, it's used in place of the dataset of the
+	// HoneycombCredentials.
 	Dataset string
 }
 
+// Credentials returns the HoneycombCredentials, with the deprecated APIKey and Dataset fields in place of theirs when
+// they're set. Use it to send metrics with the same credentials, see MeterConfig.
+func (c HoneycombTracingConfig) Credentials() HoneycombCredentials {
+	creds := c.HoneycombCredentials
+
+	if c.APIKey != "" {
+		creds.APIKey = c.APIKey
+		creds.APIKeyFile = ""
+		creds.APIKeyEnv = ""
+	}
+
+	if c.Dataset != "" {
+		creds.Dataset = c.Dataset
+	}
+
+	return creds
+}
+
 // OtelTracer sets up a trace provider that sends data to an opentelemetry collector.
 func OtelTracer(ctx context.Context, conn *grpc.ClientConn, config TracingConfig) (*trace.TracerProvider, error) {
 	exporter, err := otlptrace.New(ctx, otlptracegrpc.NewClient(
@@ -50,14 +76,17 @@ func OtelTracer(ctx context.Context, conn *grpc.ClientConn, config TracingConfig
 	return traceProvider, nil
 }
 
-// HoneycombTracer returns a tracer provider configured to send traces to your Honeycomb account.
+// HoneycombTracer returns a tracer provider configured to send traces to your Honeycomb account. The dataset header is
+// only sent when the API key belongs to a Honeycomb Classic team, in which case the dataset is also required.
 func HoneycombTracer(ctx context.Context, conn *grpc.ClientConn, config HoneycombTracingConfig) (*trace.TracerProvider, error) {
+	headers, err := config.Credentials().traceHeaders()
+	if err != nil {
+		return nil, errors.Wrap(err, "config.Credentials().traceHeaders")
+	}
+
 	exporter, err := otlptrace.New(ctx, otlptracegrpc.NewClient(
 		otlptracegrpc.WithGRPCConn(conn),
-		otlptracegrpc.WithHeaders(map[string]string{
-			"x-honeycomb-team":    config.APIKey,
-			"x-honeycomb-dataset": config.Dataset,
-		}),
+		otlptracegrpc.WithHeaders(headers),
 	))
 	if err != nil {
 		return nil, errors.Wrap(err, "oltptrace.New with exporter as honeycomb")
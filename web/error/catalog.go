@@ -0,0 +1,282 @@
+package error
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// CodeExtension is the member of the error body that holds the code of a *CodedError.
+const CodeExtension = "code"
+
+// codePattern is what codes look like: upper case letters, digits and underscores, starting with a letter.
+var codePattern = regexp.MustCompile(`^[A-Z][A-Z0-9_]*$`)
+
+// DefaultCatalog is the Catalog that Define registers codes on.
+var DefaultCatalog = NewCatalog()
+
+// Code is a stable, machine-readable identifier of an error that clients can rely on across releases, together with
+// what the error Handler sends for it.
+type Code struct {
+	// ID is the code itself, like ORDER_NOT_FOUND.
+	ID string `json:"code"`
+
+	// Status is the http status code of the response.
+	Status int `json:"status"`
+
+	// Message is the message sent to the client, unless the *CodedError has its own.
+	Message string `json:"message"`
+
+	// DocsURL points to the documentation of the error. In problem details responses it's used as the type.
+	DocsURL string `json:"docsUrl,omitempty"`
+
+	// Description is a longer explanation for the reference written by WriteMarkdown, WriteJSON and GenerateReference.
+	// It's not sent to clients.
+	Description string `json:"description,omitempty"`
+
+	// Kind is the kind of the error, used for the error.kind attribute of the error counter.
+	Kind Kind `json:"-"`
+}
+
+// Wrap returns a *CodedError with the code that wraps err. The error can be nil.
+func (c *Code) Wrap(err error) *CodedError {
+	return &CodedError{
+		Code: c,
+		Err:  err,
+	}
+}
+
+// CodedError is an error with a Code. The error Handler uses the code for the status code, the message, and the code
+// member of the body. The wrapped error is logged, but not sent to the client. A CodedError without a Code is handled
+// like the error it wraps, which is a 500 Internal Server Error for most errors.
+type CodedError struct {
+	Code    *Code
+	Message string
+	Err     error
+}
+
+// WithMessage replaces the default message of the code, and returns the same error so calls can be chained.
+func (e *CodedError) WithMessage(message string) *CodedError {
+	e.Message = message
+
+	return e
+}
+
+// Error returns the code and the message of the error, followed by the message of the wrapped error.
+func (e *CodedError) Error() string {
+	msg := e.message()
+	if e.Code != nil {
+		msg = e.Code.ID + ": " + msg
+	}
+
+	switch {
+	case e.Err == nil:
+		return msg
+	case msg == "":
+		return e.Err.Error()
+	default:
+		return msg + ": " + e.Err.Error()
+	}
+}
+
+// Unwrap returns the wrapped error.
+func (e *CodedError) Unwrap() error {
+	return e.Err
+}
+
+// message returns the message of the error, or the default one of its code.
+func (e *CodedError) message() string {
+	if e.Message != "" || e.Code == nil {
+		return e.Message
+	}
+
+	return e.Code.Message
+}
+
+// Catalog is a set of codes with unique IDs. It's safe for concurrent use.
+type Catalog struct {
+	mu    sync.RWMutex
+	codes map[string]*Code
+}
+
+// NewCatalog returns an empty Catalog.
+func NewCatalog() *Catalog {
+	return &Catalog{
+		codes: make(map[string]*Code),
+	}
+}
+
+// Register adds the code to the catalog. It returns an error if the ID is not in upper snake case, if the status is not
+// an error status, or if the ID is already registered.
+func (c *Catalog) Register(code Code) (*Code, error) {
+	if !codePattern.MatchString(code.ID) {
+		return nil, errors.Errorf("error code %q is not in upper snake case", code.ID)
+	}
+
+	if code.Status < http.StatusBadRequest || code.Status > 599 {
+		return nil, errors.Errorf("error code %s has status %d, which is not an error status", code.ID, code.Status)
+	}
+
+	if code.Message == "" {
+		code.Message = http.StatusText(code.Status)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.codes[code.ID]; ok {
+		return nil, errors.Errorf("error code %s is already registered", code.ID)
+	}
+
+	c.codes[code.ID] = &code
+
+	return &code, nil
+}
+
+// MustRegister is like Register, but panics if the code can't be registered. It's meant for package level variables.
+func (c *Catalog) MustRegister(code Code) *Code {
+	registered, err := c.Register(code)
+	if err != nil {
+		panic(err)
+	}
+
+	return registered
+}
+
+// Lookup returns the code with the ID, and false if there isn't one.
+func (c *Catalog) Lookup(id string) (*Code, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	code, ok := c.codes[id]
+
+	return code, ok
+}
+
+// Codes returns every code in the catalog, sorted by ID.
+func (c *Catalog) Codes() []Code {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	codes := make([]Code, 0, len(c.codes))
+	for _, code := range c.codes {
+		codes = append(codes, *code)
+	}
+
+	sort.Slice(codes, func(i, j int) bool {
+		return codes[i].ID < codes[j].ID
+	})
+
+	return codes
+}
+
+// WriteJSON writes the codes in the catalog to w as a JSON array, sorted by ID.
+func (c *Catalog) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return errors.Wrap(enc.Encode(c.Codes()), "enc.Encode")
+}
+
+// WriteMarkdown writes the codes in the catalog to w as a Markdown table, sorted by ID.
+func (c *Catalog) WriteMarkdown(w io.Writer) error {
+	b := strings.Builder{}
+	b.WriteString("# Error codes\n\n")
+	b.WriteString("| Code | Status | Message | Description |\n")
+	b.WriteString("|------|--------|---------|-------------|\n")
+
+	for _, code := range c.Codes() {
+		id := "`" + code.ID + "`"
+		if code.DocsURL != "" {
+			id = "[" + id + "](" + code.DocsURL + ")"
+		}
+
+		fmt.Fprintf(&b, "| %s | %d %s | %s | %s |\n",
+			id,
+			code.Status,
+			http.StatusText(code.Status),
+			markdownCell(code.Message),
+			markdownCell(code.Description),
+		)
+	}
+
+	_, err := io.WriteString(w, b.String())
+
+	return errors.Wrap(err, "io.WriteString")
+}
+
+// markdownCell escapes the text so it can be put in a Markdown table cell.
+func markdownCell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+
+	return strings.ReplaceAll(s, "\n", " ")
+}
+
+// Define registers the code on the DefaultCatalog, and panics if that fails. Services declare their codes as package
+// level variables, so a duplicate ID is caught as soon as the service starts:
+//
+//	var ErrOrderNotFound = error.Define(error.Code{
+//		ID:      "ORDER_NOT_FOUND",
+//		Status:  http.StatusNotFound,
+//		Message: "order not found",
+//		DocsURL: "https://docs.example.com/errors#ORDER_NOT_FOUND",
+//	})
+func Define(code Code) *Code {
+	return DefaultCatalog.MustRegister(code)
+}
+
+// GenerateReference is the body of a generator command that writes a reference of the codes in the catalog. The kit
+// can't ship the command, as it has to import the packages that define the service's codes, so a service adds a small
+// main package, usually cmd/errcodes, that imports them and calls this with os.Args[1:], and runs it from a file at the
+// root of the module:
+//
+//	//go:generate go run ./cmd/errcodes -format markdown -o docs/errors.md
+//
+// The flags are -format, which is markdown or json, and -o, the file to write to. Without -o the reference is written
+// to w.
+func GenerateReference(c *Catalog, args []string, w io.Writer) error {
+	fs := flag.NewFlagSet("errcodes", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	format := fs.String("format", "markdown", "output format, markdown or json")
+	out := fs.String("o", "", "file to write the reference to, w if empty")
+
+	if err := fs.Parse(args); err != nil {
+		return errors.Wrap(err, "fs.Parse")
+	}
+
+	var write func(io.Writer) error
+	switch *format {
+	case "markdown", "md":
+		write = c.WriteMarkdown
+	case "json":
+		write = c.WriteJSON
+	default:
+		return errors.Errorf("unknown format %q, use markdown or json", *format)
+	}
+
+	if *out == "" {
+		return write(w)
+	}
+
+	f, err := os.Create(*out)
+	if err != nil {
+		return errors.Wrap(err, "os.Create")
+	}
+
+	if err = write(f); err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	return errors.Wrap(f.Close(), "f.Close")
+}
@@ -0,0 +1,215 @@
+package error
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCatalog_Register(t *testing.T) {
+	tests := []struct {
+		name    string
+		code    Code
+		wantErr string
+	}{
+		{
+			name: "valid code",
+			code: Code{ID: "ORDER_NOT_FOUND", Status: http.StatusNotFound, Message: "order not found"},
+		},
+		{
+			name:    "duplicate",
+			code:    Code{ID: "ORDER_NOT_FOUND", Status: http.StatusNotFound},
+			wantErr: "error code ORDER_NOT_FOUND is already registered",
+		},
+		{
+			name:    "not upper snake case",
+			code:    Code{ID: "orderNotFound", Status: http.StatusNotFound},
+			wantErr: `error code "orderNotFound" is not in upper snake case`,
+		},
+		{
+			name:    "not an error status",
+			code:    Code{ID: "ORDER_CREATED", Status: http.StatusCreated},
+			wantErr: "error code ORDER_CREATED has status 201, which is not an error status",
+		},
+	}
+
+	c := NewCatalog()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, err := c.Register(tt.code)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				assert.Nil(t, code)
+
+				return
+			}
+
+			require.NoError(t, err)
+
+			got, ok := c.Lookup(tt.code.ID)
+			assert.True(t, ok)
+			assert.Same(t, code, got)
+		})
+	}
+}
+
+func TestCatalog_reference(t *testing.T) {
+	c := NewCatalog()
+	c.MustRegister(Code{
+		ID:          "ORDER_NOT_FOUND",
+		Status:      http.StatusNotFound,
+		Message:     "order not found",
+		DocsURL:     "https://docs.example.com/errors#ORDER_NOT_FOUND",
+		Description: "The order doesn't exist, or it belongs to another account.",
+	})
+	c.MustRegister(Code{
+		ID:     "ACCOUNT_LOCKED",
+		Status: http.StatusForbidden,
+	})
+
+	md := bytes.NewBuffer(nil)
+	require.NoError(t, c.WriteMarkdown(md))
+	assert.Equal(t, "# Error codes\n\n"+
+		"| Code | Status | Message | Description |\n"+
+		"|------|--------|---------|-------------|\n"+
+		"| `ACCOUNT_LOCKED` | 403 Forbidden | Forbidden |  |\n"+
+		"| [`ORDER_NOT_FOUND`](https://docs.example.com/errors#ORDER_NOT_FOUND) | 404 Not Found | order not found | The order doesn't exist, or it belongs to another account. |\n",
+		md.String())
+
+	js := bytes.NewBuffer(nil)
+	require.NoError(t, c.WriteJSON(js))
+	assert.JSONEq(t, `[
+		{"code":"ACCOUNT_LOCKED","status":403,"message":"Forbidden"},
+		{"code":"ORDER_NOT_FOUND","status":404,"message":"order not found",
+		 "docsUrl":"https://docs.example.com/errors#ORDER_NOT_FOUND",
+		 "description":"The order doesn't exist, or it belongs to another account."}
+	]`, js.String())
+}
+
+func TestGenerateReference(t *testing.T) {
+	c := NewCatalog()
+	c.MustRegister(Code{
+		ID:     "ACCOUNT_LOCKED",
+		Status: http.StatusForbidden,
+	})
+
+	md := bytes.NewBuffer(nil)
+	require.NoError(t, GenerateReference(c, nil, md))
+	assert.Contains(t, md.String(), "| `ACCOUNT_LOCKED` | 403 Forbidden | Forbidden |  |\n")
+
+	out := filepath.Join(t.TempDir(), "errors.json")
+	require.NoError(t, GenerateReference(c, []string{"-format", "json", "-o", out}, nil))
+
+	b, err := os.ReadFile(out)
+	require.NoError(t, err)
+	assert.JSONEq(t, `[{"code":"ACCOUNT_LOCKED","status":403,"message":"Forbidden"}]`, string(b))
+
+	assert.EqualError(t, GenerateReference(c, []string{"-format", "yaml"}, nil),
+		`unknown format "yaml", use markdown or json`)
+	assert.ErrorContains(t, GenerateReference(c, []string{"-output", "errors.md"}, nil),
+		"flag provided but not defined: -output")
+}
+
+func TestHandler_codedError(t *testing.T) {
+	c := NewCatalog()
+	orderNotFound := c.MustRegister(Code{
+		ID:      "ORDER_NOT_FOUND",
+		Status:  http.StatusNotFound,
+		Message: "order not found",
+		DocsURL: "https://docs.example.com/errors#ORDER_NOT_FOUND",
+		Kind:    KindNotFound,
+	})
+
+	tests := []struct {
+		name     string
+		options  []OptionModifier
+		err      error
+		wantBody string
+	}{
+		{
+			name:     "legacy body",
+			err:      errors.Wrap(orderNotFound.Wrap(errors.New("sql: no rows in result set")), "repo.Order"),
+			wantBody: `{"code":"ORDER_NOT_FOUND","message":"order not found","status":404}`,
+		},
+		{
+			name:     "own message",
+			err:      orderNotFound.Wrap(nil).WithMessage("order 12 not found"),
+			wantBody: `{"code":"ORDER_NOT_FOUND","message":"order 12 not found","status":404}`,
+		},
+		{
+			name:    "problem details",
+			options: []OptionModifier{WithProblemDetails()},
+			err:     orderNotFound.Wrap(nil),
+			wantBody: `{"type":"https://docs.example.com/errors#ORDER_NOT_FOUND","title":"Not Found","status":404,
+				"detail":"order not found","instance":"unknown-request","code":"ORDER_NOT_FOUND"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			e.HTTPErrorHandler = Handler(zerolog.Nop(), tt.options...)
+			e.GET("/", func(c echo.Context) error {
+				return tt.err
+			})
+
+			w := httptest.NewRecorder()
+			e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			assert.Equal(t, http.StatusNotFound, w.Code)
+			assert.JSONEq(t, tt.wantBody, w.Body.String())
+			assert.Equal(t, KindNotFound, KindOf(tt.err))
+		})
+	}
+}
+
+func TestHandler_codedErrorWithoutCode(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       *CodedError
+		wantError string
+		wantCode  int
+		wantBody  string
+	}{
+		{
+			name:      "wrapped error",
+			err:       &CodedError{Err: errors.New("sql: connection refused")},
+			wantError: "sql: connection refused",
+			wantCode:  http.StatusInternalServerError,
+			wantBody:  `{"message":"Internal Server Error","status":500}`,
+		},
+		{
+			name:      "wrapped http error",
+			err:       &CodedError{Message: "too many orders", Err: echo.ErrTooManyRequests},
+			wantError: "too many orders: code=429, message=Too Many Requests",
+			wantCode:  http.StatusTooManyRequests,
+			wantBody:  `{"message":"Too Many Requests","status":429}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			e.HTTPErrorHandler = Handler(zerolog.Nop())
+			e.GET("/", func(c echo.Context) error {
+				return tt.err
+			})
+
+			w := httptest.NewRecorder()
+			e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			assert.Equal(t, tt.wantError, tt.err.Error())
+			assert.Equal(t, tt.wantCode, w.Code)
+			assert.JSONEq(t, tt.wantBody, w.Body.String())
+			assert.Equal(t, KindUnknown, KindOf(tt.err))
+		})
+	}
+}
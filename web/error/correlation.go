@@ -0,0 +1,49 @@
+package error
+
+import (
+	"context"
+	"net/http"
+
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// HeaderXTraceID is the response header the Handler puts the trace ID in when WithCorrelationIDs is set.
+	HeaderXTraceID = "X-Trace-Id"
+
+	// RequestIDExtension is the member of the error body that holds the request ID when WithCorrelationIDs is set.
+	RequestIDExtension = "request_id"
+
+	// TraceIDExtension is the member of the error body that holds the trace ID when WithCorrelationIDs is set.
+	TraceIDExtension = "trace_id"
+)
+
+// WithCorrelationIDs makes the Handler add the request ID and the trace ID of the span in the request context to the
+// error body, so they end up in whatever the customer copies into their support ticket. Outside of debug mode the
+// traceparent and X-Trace-Id response headers are set too.
+func WithCorrelationIDs() OptionModifier {
+	return func(o *HandlerOptions) {
+		o.correlationIDs = true
+	}
+}
+
+// addCorrelationIDs adds the request ID and trace ID to the Problem. If withHeaders is true, the trace context is also
+// written to the headers. The trace ID and headers are left out if there's no valid span in the context.
+func addCorrelationIDs(ctx context.Context, p *Problem, rid string, h http.Header, withHeaders bool) {
+	p.With(RequestIDExtension, rid)
+
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return
+	}
+
+	p.With(TraceIDExtension, sc.TraceID().String())
+
+	if !withHeaders {
+		return
+	}
+
+	propagation.TraceContext{}.Inject(ctx, propagation.HeaderCarrier(h))
+	h.Set(HeaderXTraceID, sc.TraceID().String())
+}
@@ -0,0 +1,108 @@
+package error
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestHandler_correlationIDs(t *testing.T) {
+	const mockRequestID = "1f550f47-0086-4f92-8d6a-1d5805b2e20e"
+
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+		SpanID:     trace.SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+		TraceFlags: trace.FlagsSampled,
+	})
+
+	tests := []struct {
+		name            string
+		options         []OptionModifier
+		debug           bool
+		spanContext     trace.SpanContext
+		wantBody        map[string]interface{}
+		wantTraceparent string
+		wantXTraceID    string
+	}{
+		{
+			name:        "off by default",
+			spanContext: sc,
+			wantBody: map[string]interface{}{
+				"status":  float64(http.StatusNotFound),
+				"message": "order not found",
+			},
+		},
+		{
+			name:        "ids in body and headers",
+			options:     []OptionModifier{WithCorrelationIDs()},
+			spanContext: sc,
+			wantBody: map[string]interface{}{
+				"status":     float64(http.StatusNotFound),
+				"message":    "order not found",
+				"request_id": mockRequestID,
+				"trace_id":   "4bf92f3577b34da6a3ce929d0e0e4736",
+			},
+			wantTraceparent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			wantXTraceID:    "4bf92f3577b34da6a3ce929d0e0e4736",
+		},
+		{
+			name:        "no headers in debug mode",
+			options:     []OptionModifier{WithCorrelationIDs()},
+			debug:       true,
+			spanContext: sc,
+			wantBody: map[string]interface{}{
+				"status":     float64(http.StatusNotFound),
+				"message":    "order not found",
+				"request_id": mockRequestID,
+				"trace_id":   "4bf92f3577b34da6a3ce929d0e0e4736",
+				"error":      "order not found",
+			},
+		},
+		{
+			name:    "no span",
+			options: []OptionModifier{WithCorrelationIDs()},
+			wantBody: map[string]interface{}{
+				"status":     float64(http.StatusNotFound),
+				"message":    "order not found",
+				"request_id": mockRequestID,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			e.Debug = tt.debug
+			e.HTTPErrorHandler = Handler(zerolog.Nop(), tt.options...)
+			e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+				return func(c echo.Context) error {
+					c.Response().Header().Set(echo.HeaderXRequestID, mockRequestID)
+
+					ctx := trace.ContextWithSpanContext(c.Request().Context(), tt.spanContext)
+					c.SetRequest(c.Request().WithContext(ctx))
+
+					return next(c)
+				}
+			})
+			e.GET("/", func(c echo.Context) error {
+				return NotFound(nil, "order not found")
+			})
+
+			w := httptest.NewRecorder()
+			e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			var body map[string]interface{}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+
+			assert.Equal(t, tt.wantBody, body)
+			assert.Equal(t, tt.wantTraceparent, w.Header().Get("traceparent"))
+			assert.Equal(t, tt.wantXTraceID, w.Header().Get(HeaderXTraceID))
+		})
+	}
+}
@@ -1,64 +1,349 @@
 package error
 
 import (
+	"context"
+	"html/template"
 	"net/http"
+	"time"
 
 	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
 
+	"github.com/suborbital/go-kit/observability"
 	kitHttp "github.com/suborbital/go-kit/web/http"
 )
 
+// HandlerOptions represents configuration options for the error Handler.
+type HandlerOptions struct {
+	problemDetails    bool
+	statusRegistry    *StatusRegistry
+	validationStatus  int
+	htmlTemplate      *template.Template
+	correlationIDs    bool
+	defaultRetryAfter time.Duration
+	translations      *Translations
+	debug             bool
+	logPolicy         logPolicy
+}
+
+// OptionModifier is a type of function that changes values on a HandlerOptions struct in place.
+type OptionModifier func(o *HandlerOptions)
+
+// WithProblemDetails makes the Handler respond with RFC 7807 application/problem+json bodies instead of the default
+// {"status","message"} shape. The instance member is set to the request ID.
+func WithProblemDetails() OptionModifier {
+	return func(o *HandlerOptions) {
+		o.problemDetails = true
+	}
+}
+
+// WithDebug adds the error message to the response body in an "error" member. The echo Handler also does that when
+// echo is in debug mode.
+func WithDebug() OptionModifier {
+	return func(o *HandlerOptions) {
+		o.debug = true
+	}
+}
+
+// WithStatusRegistry sets the StatusRegistry used to map error kinds and sentinel errors to status codes. If not set,
+// the DefaultStatusRegistry is used.
+func WithStatusRegistry(r *StatusRegistry) OptionModifier {
+	return func(o *HandlerOptions) {
+		o.statusRegistry = r
+	}
+}
+
+// WithValidationStatus sets the status code used for a *ValidationError that doesn't have its own status set. If not
+// set, 422 Unprocessable Entity is used.
+func WithValidationStatus(status int) OptionModifier {
+	return func(o *HandlerOptions) {
+		o.validationStatus = status
+	}
+}
+
 // Handler is a modified version of echo's own DefaultHTTPErrorHandler function. It allows us to do the following:
 // - log a committed response, both that return an error, and ones that don't
 // - log all internal errors without exposing them to the client
 // - modify the response json to also include the status code in the response body
-func Handler(logger zerolog.Logger) echo.HTTPErrorHandler {
-	ll := logger.With().Str("middleware", "errorHandler").Logger()
-	return func(err error, c echo.Context) {
-		rid := kitHttp.RID(c)
+//
+// Log entries also carry the trace ID, span ID and trace flags of the span in the request context, see
+// observability.TraceHook.
+//
+// Every error increments the http.server.errors counter on the global meter, with the route, status code, and error
+// kind as attributes. Server errors are also recorded on the span in the request context, and the span's status is set
+// to error. Client errors are not, following the opentelemetry semantic conventions for server spans.
+//
+// All errors are logged at error level by default. WithClientErrorLevel and WithServerErrorLevel change the level of 4xx
+// and 5xx errors, WithSuppressedStatuses and WithSuppressedKinds turn logging off for some errors altogether, and
+// WithLogRateLimit logs the same error at most once per window.
+//
+// Errors are resolved through their whole chain of wrapped errors, so a wrapped *echo.HTTPError, a DomainError, or a
+// sentinel error registered on the StatusRegistry all get the right status code. Anything else is a 500. A
+// *ValidationError is sent with the list of invalid fields in an "errors" member.
+//
+// By default the response body is {"status":code,"message":"..."}. Use WithProblemDetails to respond with RFC 7807
+// problem details instead. Handlers can also return a *Problem, which is sent in whichever shape is configured.
+//
+// WithCorrelationIDs adds the request ID and trace ID to the body, and the trace context to the response headers.
+//
+// 429 and 503 responses get the Retry-After and RateLimit-* headers from a *RetryError in the chain, or the default set
+// with WithDefaultRetryAfter.
+//
+// WithTranslations translates the title and message into the language picked by the Accept-Language header.
+//
+// The Accept header of the request picks the representation: JSON in the configured shape, application/problem+json,
+// text/plain, or text/html if a template is set with WithHTMLTemplate. JSON is the fallback.
+func Handler(logger zerolog.Logger, options ...OptionModifier) echo.HTTPErrorHandler {
+	h := newHandler(logger, options...)
 
-		if c.Response().Committed {
-			ll.Err(err).Str("requestID", rid).Msg("response already committed")
+	return func(err error, c echo.Context) {
+		// middlewares like mid.Logger hand the error to the error handler, and then return it, the way echo's own do.
+		if kitHttp.Handled(c, err) {
 			return
 		}
 
-		ll.Err(err).
-			Str("requestID", rid).
-			Msg("request returned an error")
+		kitHttp.MarkHandled(c, err)
 
-		he, ok := err.(*echo.HTTPError)
-		if ok {
-			if he.Internal != nil {
-				if herr, ok := he.Internal.(*echo.HTTPError); ok {
-					he = herr
-				}
-			}
-		} else {
-			he = &echo.HTTPError{
-				Code:    http.StatusInternalServerError,
-				Message: http.StatusText(http.StatusInternalServerError),
-			}
+		serr := h.handle(err, errorRequest{
+			ctx:      c.Request().Context(),
+			r:        c.Request(),
+			route:    c.Path(),
+			rid:      kitHttp.RID(c),
+			debug:    h.opts.debug || c.Echo().Debug,
+			response: echoResponder{c: c},
+		})
+		if serr != nil {
+			c.Logger().Error(serr)
+		}
+	}
+}
+
+// HTTPHandler is the equivalent of Handler for services that use kitHttp.Mux, or kitHttp.HandlerFunc, instead of echo.
+// It works the same way, with the same options, and sends the same responses. The route is the pattern of the Mux route,
+// see kitHttp.Route, and the request ID is read from the response header, see kitHttp.ResponseRID. Use WithDebug to add
+// the error message to responses.
+func HTTPHandler(logger zerolog.Logger, options ...OptionModifier) kitHttp.ErrorHandler {
+	h := newHandler(logger, options...)
+
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request, err error) {
+		serr := h.handle(err, errorRequest{
+			ctx:      ctx,
+			r:        r,
+			route:    kitHttp.Route(ctx),
+			rid:      kitHttp.ResponseRID(w),
+			debug:    h.opts.debug,
+			response: httpResponder{w: w},
+		})
+		if serr != nil {
+			h.ll.Err(serr).Ctx(ctx).Msg("sending error response")
 		}
+	}
+}
+
+// handler is what Handler and HTTPHandler have in common.
+type handler struct {
+	opts HandlerOptions
+	ll   zerolog.Logger
+	t    telemetry
+}
+
+// errorRequest is the request an error is handled for.
+type errorRequest struct {
+	ctx      context.Context
+	r        *http.Request
+	route    string
+	rid      string
+	debug    bool
+	response responder
+}
+
+// newHandler applies the options, and sets up the logger and the error counter.
+func newHandler(logger zerolog.Logger, options ...OptionModifier) *handler {
+	opts := HandlerOptions{
+		statusRegistry:   DefaultStatusRegistry,
+		validationStatus: http.StatusUnprocessableEntity,
+		logPolicy: logPolicy{
+			clientErrorLevel: zerolog.ErrorLevel,
+			serverErrorLevel: zerolog.ErrorLevel,
+		},
+	}
+	for _, o := range options {
+		o(&opts)
+	}
+
+	ll := logger.With().Str("middleware", "errorHandler").Logger().Hook(observability.TraceHook{})
 
-		code := he.Code
-		message := he.Message
-		if m, ok := he.Message.(string); ok {
-			if c.Echo().Debug {
-				message = echo.Map{"status": code, "message": m, "error": err.Error()}
-			} else {
-				message = echo.Map{"status": code, "message": m}
+	t, terr := newTelemetry()
+	if terr != nil {
+		ll.Err(terr).Msg("creating error counter, errors will not be counted")
+	}
+
+	return &handler{
+		opts: opts,
+		ll:   ll,
+		t:    t,
+	}
+}
+
+// handle logs and records the error, and sends the error response. It returns the error of sending the response.
+func (h *handler) handle(err error, req errorRequest) error {
+	if status, committed := req.response.status(); committed {
+		kind := KindOf(err)
+
+		h.opts.logPolicy.event(h.ll, err, req.route, status, kind).
+			Ctx(req.ctx).
+			Str("requestID", req.rid).
+			Msg("response already committed")
+		h.t.record(req.ctx, err, req.route, status, kind)
+
+		return nil
+	}
+
+	p := resolve(err, h.opts)
+
+	h.opts.logPolicy.event(h.ll, err, req.route, p.Status, p.kind).
+		Ctx(req.ctx).
+		Str("requestID", req.rid).
+		Msg("request returned an error")
+	h.t.record(req.ctx, err, req.route, p.Status, p.kind)
+
+	if p.Instance == "" {
+		p.Instance = req.rid
+	}
+
+	if req.debug {
+		p.With("error", err.Error())
+	}
+
+	header := req.response.header()
+
+	if h.opts.correlationIDs {
+		addCorrelationIDs(req.ctx, p, req.rid, header, !req.debug)
+	}
+
+	setRetryHeaders(header, err, p.Status, h.opts)
+
+	if h.opts.translations != nil {
+		tag := h.opts.translations.localize(p, req.r.Header.Get(HeaderAcceptLanguage))
+		header.Set(HeaderContentLanguage, tag.String())
+		header.Add(echo.HeaderVary, HeaderAcceptLanguage)
+	}
+
+	// Send response
+	if req.r.Method == http.MethodHead { // Issue #608
+		return req.response.noContent(p.Status)
+	}
+
+	return render(req.response, req.r, p, h.opts)
+}
+
+// resolve turns the error returned by the handler into a Problem that can be sent to the client. The returned Problem
+// is always a new one, so it can be modified without changing the original error. The error chain is searched for, in
+// order:
+//   - a *Problem, which is used as is
+//   - a *kitHttp.DecodeError or *kitHttp.ValidateError, which are turned into a *ValidationError with FromBindError and
+//     FromValidateError first
+//   - a *CodedError, whose code is added to the "code" member, and whose docs URL is used as the problem type
+//   - a *ValidationError, whose invalid fields are added to the "errors" member
+//   - an *echo.HTTPError, which is used for the status code and message
+//   - a sentinel error, or a DomainError with a kind registered on the StatusRegistry. A DomainError's message is used
+//     as the detail, a sentinel error's message is not sent to the client
+//
+// Anything else becomes a 500 Internal Server Error.
+func resolve(err error, opts HandlerOptions) *Problem {
+	err = fromRequestError(err)
+
+	p := resolveProblem(err, opts)
+	p.kind = KindOf(err)
+
+	return p
+}
+
+// fromRequestError turns the errors kitHttp.JSON handlers return for requests that can't be decoded or aren't valid
+// into the errors FromBindError and FromValidateError return for them. Other errors are returned unchanged.
+func fromRequestError(err error) error {
+	var p *Problem
+	if errors.As(err, &p) {
+		return err
+	}
+
+	var de *kitHttp.DecodeError
+	if errors.As(err, &de) {
+		return FromBindError(de.Err)
+	}
+
+	var ve *kitHttp.ValidateError
+	if errors.As(err, &ve) {
+		return FromValidateError(ve.Err)
+	}
+
+	return err
+}
+
+// resolveProblem does the heavy lifting for resolve.
+func resolveProblem(err error, opts HandlerOptions) *Problem {
+	var p *Problem
+	if errors.As(err, &p) {
+		return p.clone()
+	}
+
+	var ce *CodedError
+	if errors.As(err, &ce) && ce.Code != nil {
+		p = NewProblem(ce.Code.Status, ce.message()).With(CodeExtension, ce.Code.ID)
+		if ce.Message == "" {
+			p.messageKey = ce.Code.ID
+		}
+		if ce.Code.DocsURL != "" {
+			p.Type = ce.Code.DocsURL
+		}
+
+		return p
+	}
+
+	var ve *ValidationError
+	if errors.As(err, &ve) {
+		status := ve.Status
+		if status == 0 {
+			status = opts.validationStatus
+		}
+
+		return NewProblem(status, ve.message()).With("errors", ve.Fields)
+	}
+
+	var he *echo.HTTPError
+	var be *echo.BindingError
+	if errors.As(err, &be) {
+		he = be.HTTPError
+	}
+
+	if he != nil || errors.As(err, &he) {
+		if he.Internal != nil {
+			if herr, ok := he.Internal.(*echo.HTTPError); ok {
+				he = herr
 			}
 		}
 
-		// Send response
-		if c.Request().Method == http.MethodHead { // Issue #608
-			err = c.NoContent(he.Code)
-		} else {
-			err = c.JSON(code, message)
+		p = NewProblem(he.Code, "")
+		p.message = he.Message
+
+		if m, ok := he.Message.(string); ok && m != p.Title {
+			p.Detail = m
 		}
-		if err != nil {
-			c.Logger().Error(err)
+
+		return p
+	}
+
+	if status, ok := opts.statusRegistry.Status(err); ok {
+		p = NewProblem(status, "")
+
+		var de *DomainError
+		if errors.As(err, &de) {
+			p.Detail = de.Message
 		}
+
+		return p
 	}
+
+	return NewProblem(http.StatusInternalServerError, "")
 }
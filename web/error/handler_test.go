@@ -5,6 +5,7 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/labstack/echo/v4"
@@ -126,3 +127,191 @@ func TestHandler(t *testing.T) {
 		})
 	}
 }
+
+func TestHandler_problemDetails(t *testing.T) {
+	mockRequestID := "1f550f47-0086-4f92-8d6a-1d5805b2e20e"
+
+	tests := []struct {
+		name            string
+		options         []OptionModifier
+		handler         echo.HandlerFunc
+		wantStatusCode  int
+		wantContentType string
+		wantBody        string
+	}{
+		{
+			name:    "http error as problem details",
+			options: []OptionModifier{WithProblemDetails()},
+			handler: func(c echo.Context) error {
+				return echo.NewHTTPError(http.StatusBadRequest, "ohno")
+			},
+			wantStatusCode:  http.StatusBadRequest,
+			wantContentType: MIMEApplicationProblemJSON,
+			wantBody: `{"detail":"ohno","instance":"` + mockRequestID + `","status":400,"title":"Bad Request",` +
+				`"type":"about:blank"}`,
+		},
+		{
+			name:    "unknown error as problem details",
+			options: []OptionModifier{WithProblemDetails()},
+			handler: func(c echo.Context) error {
+				return errors.New("database is on fire")
+			},
+			wantStatusCode:  http.StatusInternalServerError,
+			wantContentType: MIMEApplicationProblemJSON,
+			wantBody: `{"instance":"` + mockRequestID + `","status":500,"title":"Internal Server Error",` +
+				`"type":"about:blank"}`,
+		},
+		{
+			name:    "wrapped problem with extensions as problem details",
+			options: []OptionModifier{WithProblemDetails()},
+			handler: func(c echo.Context) error {
+				p := NewProblem(http.StatusConflict, "order already shipped").With("orderID", "o-12")
+				p.Type = "https://example.com/problems/order-shipped"
+
+				return errors.Wrap(p, "shipping order")
+			},
+			wantStatusCode:  http.StatusConflict,
+			wantContentType: MIMEApplicationProblemJSON,
+			wantBody: `{"detail":"order already shipped","instance":"` + mockRequestID + `","orderID":"o-12",` +
+				`"status":409,"title":"Conflict","type":"https://example.com/problems/order-shipped"}`,
+		},
+		{
+			name: "problem in legacy shape",
+			handler: func(c echo.Context) error {
+				return NewProblem(http.StatusConflict, "order already shipped")
+			},
+			wantStatusCode:  http.StatusConflict,
+			wantContentType: echo.MIMEApplicationJSONCharsetUTF8,
+			wantBody:        `{"message":"order already shipped","status":409}`,
+		},
+		{
+			name: "problem without detail in legacy shape",
+			handler: func(c echo.Context) error {
+				return NewProblem(http.StatusNotFound, "")
+			},
+			wantStatusCode:  http.StatusNotFound,
+			wantContentType: echo.MIMEApplicationJSONCharsetUTF8,
+			wantBody:        `{"message":"Not Found","status":404}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			e.Use(mid.UUIDRequestID())
+			e.HTTPErrorHandler = Handler(zerolog.Nop(), tt.options...)
+			e.GET("/", tt.handler)
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Add(echo.HeaderXRequestID, mockRequestID)
+			w := httptest.NewRecorder()
+
+			e.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatusCode, w.Result().StatusCode)
+			assert.Equal(t, tt.wantContentType, w.Result().Header.Get(echo.HeaderContentType))
+			assert.JSONEq(t, tt.wantBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_errorResolution(t *testing.T) {
+	errSentinel := errors.New("payment declined")
+	errTeapot := errors.New("i'm a teapot")
+
+	registry := NewStatusRegistry()
+	registry.RegisterError(errSentinel, http.StatusPaymentRequired)
+	registry.RegisterKind(Kind("teapot"), http.StatusTeapot)
+
+	tests := []struct {
+		name     string
+		registry *StatusRegistry
+		err      error
+		wantBody string
+	}{
+		{
+			name:     "wrapped http error",
+			err:      errors.Wrap(echo.NewHTTPError(http.StatusBadRequest, "ohno"), "wrapping thing"),
+			wantBody: `{"message":"ohno","status":400}`,
+		},
+		{
+			name:     "domain error",
+			err:      NotFound(errors.New("sql: no rows in result set"), "order not found"),
+			wantBody: `{"message":"order not found","status":404}`,
+		},
+		{
+			name:     "wrapped domain error",
+			err:      errors.Wrap(Conflict(nil, "order already shipped"), "shipping order"),
+			wantBody: `{"message":"order already shipped","status":409}`,
+		},
+		{
+			name:     "domain error without message",
+			err:      Unavailable(errors.New("connection refused"), ""),
+			wantBody: `{"message":"Service Unavailable","status":503}`,
+		},
+		{
+			name:     "domain error with unregistered kind",
+			err:      Wrap(Kind("teapot"), nil, "short and stout"),
+			wantBody: `{"message":"Internal Server Error","status":500}`,
+		},
+		{
+			name:     "domain error with kind registered on custom registry",
+			registry: registry,
+			err:      Wrap(Kind("teapot"), nil, "short and stout"),
+			wantBody: `{"message":"short and stout","status":418}`,
+		},
+		{
+			name:     "wrapped sentinel error does not expose its message",
+			registry: registry,
+			err:      errors.Wrap(errSentinel, "charging card"),
+			wantBody: `{"message":"Payment Required","status":402}`,
+		},
+		{
+			name:     "unregistered sentinel error",
+			registry: registry,
+			err:      errors.Wrap(errTeapot, "brewing"),
+			wantBody: `{"message":"Internal Server Error","status":500}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var options []OptionModifier
+			if tt.registry != nil {
+				options = append(options, WithStatusRegistry(tt.registry))
+			}
+
+			e := echo.New()
+			e.HTTPErrorHandler = Handler(zerolog.Nop(), options...)
+			e.GET("/", func(c echo.Context) error {
+				return tt.err
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			w := httptest.NewRecorder()
+
+			e.ServeHTTP(w, req)
+
+			assert.JSONEq(t, tt.wantBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_handledOnce(t *testing.T) {
+	b := bytes.NewBuffer(nil)
+
+	// mid.Logger passes the error to the error handler, and then returns it, so echo passes it to the error handler
+	// again.
+	e := echo.New()
+	e.Use(mid.Logger(zerolog.Nop(), nil))
+	e.HTTPErrorHandler = Handler(zerolog.New(b))
+	e.GET("/", func(c echo.Context) error {
+		return echo.NewHTTPError(http.StatusBadRequest, "ohno")
+	})
+
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.JSONEq(t, `{"message":"ohno","status":400}`, w.Body.String())
+	assert.Equal(t, 1, strings.Count(b.String(), "request returned an error"))
+	assert.NotContains(t, b.String(), "response already committed")
+}
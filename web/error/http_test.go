@@ -0,0 +1,116 @@
+package error
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+
+	kitHttp "github.com/suborbital/go-kit/web/http"
+)
+
+func TestHTTPHandler_parity(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []OptionModifier
+		method  string
+		accept  string
+		err     error
+	}{
+		{
+			name:   "http error",
+			method: http.MethodGet,
+			err:    echo.NewHTTPError(http.StatusBadRequest, "ohno"),
+		},
+		{
+			name:   "unknown error",
+			method: http.MethodGet,
+			err:    errors.New("oh no"),
+		},
+		{
+			name:    "problem details",
+			options: []OptionModifier{WithProblemDetails()},
+			method:  http.MethodGet,
+			err:     NotFound(nil, "order not found"),
+		},
+		{
+			name:   "validation error",
+			method: http.MethodPost,
+			err:    NewValidationError().Add("email", "email", "must be an email address"),
+		},
+		{
+			name:   "plain text",
+			method: http.MethodGet,
+			accept: "text/plain",
+			err:    Conflict(nil, "order already exists"),
+		},
+		{
+			name:    "retry",
+			options: []OptionModifier{WithDebug()},
+			method:  http.MethodGet,
+			err:     Retry(RateLimited(nil, "slow down"), time.Second).WithRateLimit(10, 0, time.Second),
+		},
+		{
+			name:   "head",
+			method: http.MethodHead,
+			err:    echo.ErrForbidden,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			const route = "/orders/:id"
+
+			e := echo.New()
+			e.HTTPErrorHandler = Handler(zerolog.Nop(), tt.options...)
+			e.Add(tt.method, route, func(c echo.Context) error {
+				return tt.err
+			})
+
+			req := httptest.NewRequest(tt.method, "/orders/12", nil)
+			req.Header.Set(echo.HeaderAccept, tt.accept)
+			echoW := httptest.NewRecorder()
+
+			e.ServeHTTP(echoW, req)
+
+			h := kitHttp.HandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+				return tt.err
+			}, HTTPHandler(zerolog.Nop(), tt.options...))
+
+			req = httptest.NewRequest(tt.method, "/orders/12", nil)
+			req.Header.Set(echo.HeaderAccept, tt.accept)
+			httpW := httptest.NewRecorder()
+
+			h.ServeHTTP(httpW, req)
+
+			assert.Equal(t, echoW.Code, httpW.Code)
+			assert.Equal(t, echoW.Header(), httpW.Header())
+			assert.Equal(t, echoW.Body.String(), httpW.Body.String())
+		})
+	}
+}
+
+func TestHTTPHandler_committed(t *testing.T) {
+	b := bytes.NewBuffer(nil)
+
+	h := kitHttp.HandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write([]byte("all good"))
+
+		return errors.New("too late")
+	}, HTTPHandler(zerolog.New(b)))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.Equal(t, "all good", w.Body.String())
+	assert.Contains(t, b.String(), `"message":"response already committed"`)
+	assert.Contains(t, b.String(), "too late")
+}
@@ -0,0 +1,168 @@
+package error
+
+import (
+	"encoding/json"
+	"io/fs"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/BurntSushi/toml"
+	"github.com/pkg/errors"
+	"golang.org/x/text/language"
+)
+
+const (
+	// HeaderAcceptLanguage is the request header the language of error messages is picked with.
+	HeaderAcceptLanguage = "Accept-Language"
+
+	// HeaderContentLanguage is the response header that holds the language of a translated error message.
+	HeaderContentLanguage = "Content-Language"
+)
+
+// Translations holds error messages in several languages, keyed by message. A message key is whatever the error
+// carries as its message: the message of an *echo.HTTPError or a DomainError, the default message or ID of a Code, or a
+// standard status text like "Not Found". It's safe for concurrent use.
+type Translations struct {
+	mu       sync.RWMutex
+	tags     []language.Tag
+	matcher  language.Matcher
+	messages map[language.Tag]map[string]string
+}
+
+// NewTranslations returns empty Translations. The fallback language is used for clients whose Accept-Language header
+// doesn't match any of the languages, and for keys that are missing from the matched language.
+func NewTranslations(fallback language.Tag) *Translations {
+	t := &Translations{
+		tags: []language.Tag{fallback},
+		messages: map[language.Tag]map[string]string{
+			fallback: {},
+		},
+	}
+	t.matcher = language.NewMatcher(t.tags)
+
+	return t
+}
+
+// Add adds the messages for the language, replacing existing ones with the same keys.
+func (t *Translations) Add(tag language.Tag, messages map[string]string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	m, ok := t.messages[tag]
+	if !ok {
+		m = make(map[string]string, len(messages))
+		t.messages[tag] = m
+		t.tags = append(t.tags, tag)
+		t.matcher = language.NewMatcher(t.tags)
+	}
+
+	for k, v := range messages {
+		m[k] = v
+	}
+}
+
+// LoadFS loads every .json and .toml file in the dir of fsys, which is usually an embed.FS. Each file holds a flat map
+// of message keys to messages, and is named after its language, like de.json or pt-BR.toml.
+func (t *Translations) LoadFS(fsys fs.FS, dir string) error {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return errors.Wrap(err, "fs.ReadDir")
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		ext := path.Ext(entry.Name())
+		if ext != ".json" && ext != ".toml" {
+			continue
+		}
+
+		tag, err := language.Parse(strings.TrimSuffix(entry.Name(), ext))
+		if err != nil {
+			return errors.Wrapf(err, "language.Parse for file %s", entry.Name())
+		}
+
+		b, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return errors.Wrap(err, "fs.ReadFile")
+		}
+
+		messages := make(map[string]string)
+		if ext == ".json" {
+			err = json.Unmarshal(b, &messages)
+		} else {
+			err = toml.Unmarshal(b, &messages)
+		}
+
+		if err != nil {
+			return errors.Wrapf(err, "decoding translations in %s", entry.Name())
+		}
+
+		t.Add(tag, messages)
+	}
+
+	return nil
+}
+
+// Match returns the language that fits the Accept-Language header best, or the fallback language.
+func (t *Translations) Match(acceptLanguage string) language.Tag {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	_, i := language.MatchStrings(t.matcher, acceptLanguage)
+
+	return t.tags[i]
+}
+
+// Message returns the message for the key in the language, or in the fallback language if the language doesn't have
+// it. It returns false if neither does.
+func (t *Translations) Message(tag language.Tag, key string) (string, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if m, ok := t.messages[tag][key]; ok {
+		return m, true
+	}
+
+	m, ok := t.messages[t.tags[0]][key]
+
+	return m, ok
+}
+
+// WithTranslations makes the Handler translate the title and message of errors into the language picked by the
+// Accept-Language header of the request. Messages without a translation are sent as they are.
+func WithTranslations(t *Translations) OptionModifier {
+	return func(o *HandlerOptions) {
+		o.translations = t
+	}
+}
+
+// localize translates the Problem into the language that fits the Accept-Language header best, and returns that
+// language.
+func (t *Translations) localize(p *Problem, acceptLanguage string) language.Tag {
+	tag := t.Match(acceptLanguage)
+
+	if m, ok := t.Message(tag, p.Title); ok {
+		p.Title = m
+	}
+
+	key := p.messageKey
+	if key == "" {
+		key = p.Detail
+	}
+
+	if m, ok := t.Message(tag, key); ok && key != "" {
+		p.Detail = m
+	}
+
+	if s, ok := p.message.(string); ok {
+		if m, ok := t.Message(tag, s); ok {
+			p.message = m
+		}
+	}
+
+	return tag
+}
@@ -0,0 +1,181 @@
+package error
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/language"
+)
+
+func testTranslations(t *testing.T) *Translations {
+	t.Helper()
+
+	fsys := fstest.MapFS{
+		"i18n/en.json": &fstest.MapFile{Data: []byte(`{
+			"ORDER_NOT_FOUND": "The order does not exist."
+		}`)},
+		"i18n/de.json": &fstest.MapFile{Data: []byte(`{
+			"Not Found": "Nicht gefunden",
+			"Too Many Requests": "Zu viele Anfragen",
+			"order not found": "Bestellung nicht gefunden",
+			"ORDER_NOT_FOUND": "Die Bestellung existiert nicht."
+		}`)},
+		"i18n/pt-BR.toml": &fstest.MapFile{Data: []byte(`
+"Not Found" = "Não encontrado"
+"order not found" = "Pedido não encontrado"
+`)},
+		"i18n/readme.md": &fstest.MapFile{Data: []byte(`not a translation`)},
+	}
+
+	tr := NewTranslations(language.English)
+	require.NoError(t, tr.LoadFS(fsys, "i18n"))
+
+	return tr
+}
+
+func TestTranslations_LoadFS(t *testing.T) {
+	tr := testTranslations(t)
+
+	tests := []struct {
+		name           string
+		acceptLanguage string
+		key            string
+		wantTag        language.Tag
+		wantMessage    string
+		wantOK         bool
+	}{
+		{
+			name:           "json",
+			acceptLanguage: "de-CH, de;q=0.9, en;q=0.5",
+			key:            "order not found",
+			wantTag:        language.German,
+			wantMessage:    "Bestellung nicht gefunden",
+			wantOK:         true,
+		},
+		{
+			name:           "toml",
+			acceptLanguage: "pt-BR",
+			key:            "order not found",
+			wantTag:        language.BrazilianPortuguese,
+			wantMessage:    "Pedido não encontrado",
+			wantOK:         true,
+		},
+		{
+			name:           "missing key falls back to the fallback language",
+			acceptLanguage: "pt-BR",
+			key:            "ORDER_NOT_FOUND",
+			wantTag:        language.BrazilianPortuguese,
+			wantMessage:    "The order does not exist.",
+			wantOK:         true,
+		},
+		{
+			name:           "unknown language",
+			acceptLanguage: "fr",
+			key:            "order not found",
+			wantTag:        language.English,
+			wantOK:         false,
+		},
+		{
+			name:           "no header",
+			acceptLanguage: "",
+			key:            "ORDER_NOT_FOUND",
+			wantTag:        language.English,
+			wantMessage:    "The order does not exist.",
+			wantOK:         true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tag := tr.Match(tt.acceptLanguage)
+			assert.Equal(t, tt.wantTag, tag)
+
+			m, ok := tr.Message(tag, tt.key)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.wantMessage, m)
+		})
+	}
+}
+
+func TestTranslations_LoadFS_invalid(t *testing.T) {
+	tr := NewTranslations(language.English)
+
+	err := tr.LoadFS(fstest.MapFS{"i18n/de.json": &fstest.MapFile{Data: []byte(`["nope"]`)}}, "i18n")
+	assert.ErrorContains(t, err, "decoding translations in de.json")
+
+	err = tr.LoadFS(fstest.MapFS{"i18n/not a language.json": &fstest.MapFile{Data: []byte(`{}`)}}, "i18n")
+	assert.ErrorContains(t, err, "language.Parse for file not a language.json")
+}
+
+func TestHandler_translations(t *testing.T) {
+	orderNotFound := NewCatalog().MustRegister(Code{
+		ID:      "ORDER_NOT_FOUND",
+		Status:  http.StatusNotFound,
+		Message: "order not found",
+	})
+
+	tests := []struct {
+		name                string
+		options             []OptionModifier
+		err                 error
+		acceptLanguage      string
+		wantBody            string
+		wantContentLanguage string
+	}{
+		{
+			name:                "http error message",
+			err:                 echo.NewHTTPError(http.StatusNotFound, "order not found"),
+			acceptLanguage:      "de",
+			wantBody:            `{"message":"Bestellung nicht gefunden","status":404}`,
+			wantContentLanguage: "de",
+		},
+		{
+			name:                "status text",
+			err:                 echo.ErrTooManyRequests,
+			acceptLanguage:      "de",
+			wantBody:            `{"message":"Zu viele Anfragen","status":429}`,
+			wantContentLanguage: "de",
+		},
+		{
+			name:                "code",
+			options:             []OptionModifier{WithProblemDetails()},
+			err:                 orderNotFound.Wrap(nil),
+			acceptLanguage:      "de",
+			wantBody:            `{"type":"about:blank","title":"Nicht gefunden","status":404,"detail":"Die Bestellung existiert nicht.","instance":"unknown-request","code":"ORDER_NOT_FOUND"}`,
+			wantContentLanguage: "de",
+		},
+		{
+			name:                "domain error, fallback language",
+			err:                 NotFound(nil, "order not found"),
+			acceptLanguage:      "fr",
+			wantBody:            `{"message":"order not found","status":404}`,
+			wantContentLanguage: "en",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := append([]OptionModifier{WithTranslations(testTranslations(t))}, tt.options...)
+
+			e := echo.New()
+			e.HTTPErrorHandler = Handler(zerolog.Nop(), options...)
+			e.GET("/", func(c echo.Context) error {
+				return tt.err
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set(HeaderAcceptLanguage, tt.acceptLanguage)
+			w := httptest.NewRecorder()
+
+			e.ServeHTTP(w, req)
+
+			assert.JSONEq(t, tt.wantBody, w.Body.String())
+			assert.Equal(t, tt.wantContentLanguage, w.Header().Get(HeaderContentLanguage))
+			assert.Equal(t, HeaderAcceptLanguage, w.Header().Get(echo.HeaderVary))
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package error
+
+import (
+	"github.com/pkg/errors"
+)
+
+// Kind is the category of a domain error. The error Handler maps kinds to http status codes through a StatusRegistry,
+// so the code that returns the error doesn't need to know anything about http.
+type Kind string
+
+const (
+	KindUnknown      Kind = ""
+	KindNotFound     Kind = "not_found"
+	KindConflict     Kind = "conflict"
+	KindInvalid      Kind = "invalid"
+	KindUnauthorized Kind = "unauthorized"
+	KindForbidden    Kind = "forbidden"
+	KindUnavailable  Kind = "unavailable"
+	KindRateLimited  Kind = "rate_limited"
+)
+
+// DomainError is an error with a Kind, and a message that is safe to show to the client. The wrapped cause is logged,
+// but never sent to the client.
+type DomainError struct {
+	Kind    Kind
+	Message string
+	Err     error
+}
+
+// Error implements the error interface.
+func (e *DomainError) Error() string {
+	if e.Err == nil {
+		return e.Message
+	}
+
+	if e.Message == "" {
+		return e.Err.Error()
+	}
+
+	return e.Message + ": " + e.Err.Error()
+}
+
+// Unwrap returns the cause of the error.
+func (e *DomainError) Unwrap() error {
+	return e.Err
+}
+
+// Wrap returns a new DomainError of the passed in kind with the message, wrapping err. The err can be nil if there's
+// no underlying cause.
+func Wrap(kind Kind, err error, message string) *DomainError {
+	return &DomainError{
+		Kind:    kind,
+		Message: message,
+		Err:     err,
+	}
+}
+
+// NotFound returns a DomainError of KindNotFound wrapping err, which can be nil.
+func NotFound(err error, message string) *DomainError {
+	return Wrap(KindNotFound, err, message)
+}
+
+// Conflict returns a DomainError of KindConflict wrapping err, which can be nil.
+func Conflict(err error, message string) *DomainError {
+	return Wrap(KindConflict, err, message)
+}
+
+// Invalid returns a DomainError of KindInvalid wrapping err, which can be nil.
+func Invalid(err error, message string) *DomainError {
+	return Wrap(KindInvalid, err, message)
+}
+
+// Unauthorized returns a DomainError of KindUnauthorized wrapping err, which can be nil.
+func Unauthorized(err error, message string) *DomainError {
+	return Wrap(KindUnauthorized, err, message)
+}
+
+// Forbidden returns a DomainError of KindForbidden wrapping err, which can be nil.
+func Forbidden(err error, message string) *DomainError {
+	return Wrap(KindForbidden, err, message)
+}
+
+// Unavailable returns a DomainError of KindUnavailable wrapping err, which can be nil.
+func Unavailable(err error, message string) *DomainError {
+	return Wrap(KindUnavailable, err, message)
+}
+
+// RateLimited returns a DomainError of KindRateLimited wrapping err, which can be nil.
+func RateLimited(err error, message string) *DomainError {
+	return Wrap(KindRateLimited, err, message)
+}
+
+// KindOf returns the kind of the first DomainError in the chain of err, the kind of the code of a CodedError in the
+// chain, KindInvalid if there's a ValidationError in the chain instead, or KindUnknown if there's none of them.
+func KindOf(err error) Kind {
+	var de *DomainError
+	if errors.As(err, &de) {
+		return de.Kind
+	}
+
+	var ce *CodedError
+	if errors.As(err, &ce) && ce.Code != nil {
+		return ce.Code.Kind
+	}
+
+	var ve *ValidationError
+	if errors.As(err, &ve) {
+		return KindInvalid
+	}
+
+	return KindUnknown
+}
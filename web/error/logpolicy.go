@@ -0,0 +1,309 @@
+package error
+
+import (
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+// maxFingerprints is the number of distinct errors the log rate limiter keeps track of. Once it's reached, errors with
+// new fingerprints are logged without rate limiting until old ones expire.
+const maxFingerprints = 10000
+
+// logPolicy decides whether and at which level the error handler logs an error.
+type logPolicy struct {
+	clientErrorLevel   zerolog.Level
+	serverErrorLevel   zerolog.Level
+	suppressedStatuses map[int]struct{}
+	suppressedKinds    map[Kind]struct{}
+	limiter            *logLimiter
+}
+
+// WithClientErrorLevel sets the level 4xx errors are logged at. If not set, they are logged at error level.
+func WithClientErrorLevel(level zerolog.Level) OptionModifier {
+	return func(o *HandlerOptions) {
+		o.logPolicy.clientErrorLevel = level
+	}
+}
+
+// WithServerErrorLevel sets the level 5xx errors are logged at. If not set, they are logged at error level.
+func WithServerErrorLevel(level zerolog.Level) OptionModifier {
+	return func(o *HandlerOptions) {
+		o.logPolicy.serverErrorLevel = level
+	}
+}
+
+// WithSuppressedStatuses turns off logging for errors that resolve to any of the status codes, for example 404s from
+// scanners. The errors are still counted and recorded on spans.
+func WithSuppressedStatuses(statuses ...int) OptionModifier {
+	return func(o *HandlerOptions) {
+		if o.logPolicy.suppressedStatuses == nil {
+			o.logPolicy.suppressedStatuses = make(map[int]struct{})
+		}
+
+		for _, s := range statuses {
+			o.logPolicy.suppressedStatuses[s] = struct{}{}
+		}
+	}
+}
+
+// WithSuppressedKinds turns off logging for errors of any of the kinds. The errors are still counted and recorded on
+// spans.
+func WithSuppressedKinds(kinds ...Kind) OptionModifier {
+	return func(o *HandlerOptions) {
+		if o.logPolicy.suppressedKinds == nil {
+			o.logPolicy.suppressedKinds = make(map[Kind]struct{})
+		}
+
+		for _, k := range kinds {
+			o.logPolicy.suppressedKinds[k] = struct{}{}
+		}
+	}
+}
+
+// WithLogRateLimit logs the same error at most once per window. Errors are the same if they have the same route, status
+// code, and code, see CodedError, or if they have no code, the same kind, the same type of the innermost error of their
+// chain, and the same message once numbers, UUIDs and hex IDs are taken out of it. Repeats within the window are
+// counted, and the count is added to the next log line of that error in a "repeated" field. If the error doesn't happen
+// again after the window, its count is logged in an "error repeated" line of its own, the next time any error is
+// handled.
+func WithLogRateLimit(window time.Duration) OptionModifier {
+	return func(o *HandlerOptions) {
+		o.logPolicy.limiter = newLogLimiter(window)
+	}
+}
+
+// event returns the log event for the error, or nil if it should not be logged. It also logs the repeats of the errors
+// whose window passed without them happening again.
+func (lp logPolicy) event(l zerolog.Logger, err error, route string, status int, kind Kind) *zerolog.Event {
+	if _, ok := lp.suppressedStatuses[status]; ok {
+		return nil
+	}
+
+	if _, ok := lp.suppressedKinds[kind]; ok {
+		return nil
+	}
+
+	repeated, ok, flushed := lp.limiter.allow(route, status, err)
+
+	for _, r := range flushed {
+		l.WithLevel(lp.level(r.status)).
+			Str(zerolog.ErrorFieldName, r.message).
+			Str("route", r.route).
+			Int("status", r.status).
+			Int("repeated", r.repeated).
+			Msg("error repeated")
+	}
+
+	if !ok {
+		return nil
+	}
+
+	e := l.WithLevel(lp.level(status)).Err(err)
+	if repeated > 0 {
+		e = e.Int("repeated", repeated)
+	}
+
+	return e
+}
+
+// level returns the level errors that resolve to the status code are logged at.
+func (lp logPolicy) level(status int) zerolog.Level {
+	if status >= http.StatusInternalServerError || status < http.StatusBadRequest {
+		return lp.serverErrorLevel
+	}
+
+	return lp.clientErrorLevel
+}
+
+// logLimiter counts repeats of the same error within a window.
+type logLimiter struct {
+	window time.Duration
+	now    func() time.Time
+
+	mu      sync.Mutex
+	entries map[uint64]*limiterEntry
+
+	// nextFlush is when the earliest window with repeats that weren't logged yet ends, zero if there are none.
+	nextFlush time.Time
+}
+
+// limiterEntry is the state of one error fingerprint, with the route, status code and message of the error that was
+// logged last.
+type limiterEntry struct {
+	windowStart time.Time
+	repeated    int
+	route       string
+	status      int
+	message     string
+}
+
+// newLogLimiter returns a logLimiter with the window.
+func newLogLimiter(window time.Duration) *logLimiter {
+	return &logLimiter{
+		window:  window,
+		now:     time.Now,
+		entries: make(map[uint64]*limiterEntry),
+	}
+}
+
+// allow reports whether the error should be logged, and if so, how many times it was repeated since it was last logged.
+// It also returns the other entries whose window passed with repeats that weren't logged, which are forgotten
+// afterwards. A nil limiter allows everything.
+func (ll *logLimiter) allow(route string, status int, err error) (int, bool, []limiterEntry) {
+	if ll == nil {
+		return 0, true, nil
+	}
+
+	fp := fingerprint(route, status, err)
+	now := ll.now()
+
+	ll.mu.Lock()
+	defer ll.mu.Unlock()
+
+	e, ok := ll.entries[fp]
+	if ok && now.Sub(e.windowStart) < ll.window {
+		e.repeated++
+
+		if end := e.windowStart.Add(ll.window); e.repeated == 1 && (ll.nextFlush.IsZero() || end.Before(ll.nextFlush)) {
+			ll.nextFlush = end
+		}
+
+		return 0, false, ll.flush(now)
+	}
+
+	if ok {
+		repeated := e.repeated
+		e.windowStart = now
+		e.repeated = 0
+		e.message = errorMessage(err)
+
+		return repeated, true, ll.flush(now)
+	}
+
+	flushed := ll.flush(now)
+
+	if len(ll.entries) >= maxFingerprints {
+		ll.evict(now)
+	}
+
+	if len(ll.entries) < maxFingerprints {
+		ll.entries[fp] = &limiterEntry{
+			windowStart: now,
+			route:       route,
+			status:      status,
+			message:     errorMessage(err),
+		}
+	}
+
+	return 0, true, flushed
+}
+
+// flush removes the entries whose window passed with repeats, and returns them. It only looks at the entries once the
+// earliest of those windows ended. The mutex needs to be held.
+func (ll *logLimiter) flush(now time.Time) []limiterEntry {
+	if ll.nextFlush.IsZero() || now.Before(ll.nextFlush) {
+		return nil
+	}
+
+	var flushed []limiterEntry
+
+	ll.nextFlush = time.Time{}
+
+	for fp, e := range ll.entries {
+		if e.repeated == 0 {
+			continue
+		}
+
+		end := e.windowStart.Add(ll.window)
+		if !now.Before(end) {
+			flushed = append(flushed, *e)
+			delete(ll.entries, fp)
+
+			continue
+		}
+
+		if ll.nextFlush.IsZero() || end.Before(ll.nextFlush) {
+			ll.nextFlush = end
+		}
+	}
+
+	return flushed
+}
+
+// evict removes the entries whose window has passed. The mutex needs to be held, and flush needs to have run, so the
+// expired entries have no repeats that weren't logged.
+func (ll *logLimiter) evict(now time.Time) {
+	for fp, e := range ll.entries {
+		if now.Sub(e.windowStart) >= ll.window {
+			delete(ll.entries, fp)
+		}
+	}
+}
+
+// errorMessage returns the message of the error, or an empty string for nil.
+func errorMessage(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	return err.Error()
+}
+
+// fingerprint identifies an error for rate limiting purposes, by its route, status code, and errorIdentity.
+func fingerprint(route string, status int, err error) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(route))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write([]byte(strconv.Itoa(status)))
+	_, _ = h.Write([]byte{0})
+
+	if err != nil {
+		_, _ = h.Write([]byte(errorIdentity(err)))
+	}
+
+	return h.Sum64()
+}
+
+// errorIdentity tells errors apart: it's the ID of the code of a *CodedError in the chain, or the kind of the error
+// together with the type of the innermost error of the chain, and the normalized message.
+func errorIdentity(err error) string {
+	var ce *CodedError
+	if errors.As(err, &ce) && ce.Code != nil {
+		return "code " + ce.Code.ID
+	}
+
+	root := err
+	for {
+		next := errors.Unwrap(root)
+		if next == nil {
+			break
+		}
+
+		root = next
+	}
+
+	return fmt.Sprintf("kind %s %T %s", KindOf(err), root, normalizeMessage(err.Error()))
+}
+
+var (
+	uuidPattern   = regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`)
+	hexIDPattern  = regexp.MustCompile(`\b(0[xX][0-9a-fA-F]+|[0-9a-fA-F]{8,})\b`)
+	numberPattern = regexp.MustCompile(`[0-9]+`)
+)
+
+// normalizeMessage replaces the UUIDs, hex IDs and numbers in an error message with #, so errors that only differ in
+// the IDs of the things they're about have the same message.
+func normalizeMessage(msg string) string {
+	msg = uuidPattern.ReplaceAllString(msg, "#")
+	msg = hexIDPattern.ReplaceAllString(msg, "#")
+
+	return numberPattern.ReplaceAllString(msg, "#")
+}
@@ -0,0 +1,313 @@
+package error
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_logPolicy(t *testing.T) {
+	tests := []struct {
+		name      string
+		options   []OptionModifier
+		err       error
+		wantLevel string
+	}{
+		{
+			name:      "client error at error level by default",
+			err:       NotFound(nil, "order not found"),
+			wantLevel: "error",
+		},
+		{
+			name:      "client error at configured level",
+			options:   []OptionModifier{WithClientErrorLevel(zerolog.WarnLevel)},
+			err:       echo.ErrUnauthorized,
+			wantLevel: "warn",
+		},
+		{
+			name:      "server error at configured level",
+			options:   []OptionModifier{WithClientErrorLevel(zerolog.DebugLevel), WithServerErrorLevel(zerolog.ErrorLevel)},
+			err:       errors.New("oh no"),
+			wantLevel: "error",
+		},
+		{
+			name:    "suppressed status",
+			options: []OptionModifier{WithSuppressedStatuses(http.StatusNotFound, http.StatusUnauthorized)},
+			err:     echo.ErrNotFound,
+		},
+		{
+			name:    "suppressed kind",
+			options: []OptionModifier{WithSuppressedKinds(KindConflict)},
+			err:     errors.Wrap(Conflict(nil, "order already exists"), "creating order"),
+		},
+		{
+			name:      "other kinds are not suppressed",
+			options:   []OptionModifier{WithSuppressedKinds(KindConflict)},
+			err:       Unavailable(nil, ""),
+			wantLevel: "error",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := bytes.NewBuffer(nil)
+
+			e := echo.New()
+			e.HTTPErrorHandler = Handler(zerolog.New(b), tt.options...)
+			e.GET("/", func(c echo.Context) error {
+				return tt.err
+			})
+
+			e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+			if tt.wantLevel == "" {
+				assert.Empty(t, b.String())
+				return
+			}
+
+			var line map[string]interface{}
+			require.NoError(t, json.Unmarshal(b.Bytes(), &line))
+			assert.Equal(t, tt.wantLevel, line["level"])
+		})
+	}
+}
+
+func TestHandler_logRateLimit(t *testing.T) {
+	b := bytes.NewBuffer(nil)
+
+	e := echo.New()
+	e.HTTPErrorHandler = Handler(zerolog.New(b), WithLogRateLimit(time.Hour))
+	e.GET("/a", func(c echo.Context) error {
+		return errors.New("connection refused")
+	})
+	e.GET("/b", func(c echo.Context) error {
+		return errors.New("connection refused")
+	})
+
+	for i := 0; i < 100; i++ {
+		e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/a", nil))
+	}
+
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/b", nil))
+
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	assert.Len(t, lines, 2, "same error on a different route should be logged on its own")
+}
+
+func TestHandler_logRateLimit_flush(t *testing.T) {
+	b := bytes.NewBuffer(nil)
+
+	now := time.Date(2023, 8, 1, 12, 0, 0, 0, time.UTC)
+	clock := func(o *HandlerOptions) {
+		o.logPolicy.limiter.now = func() time.Time {
+			return now
+		}
+	}
+
+	e := echo.New()
+	e.HTTPErrorHandler = Handler(zerolog.New(b), WithLogRateLimit(time.Minute), clock)
+
+	errs := []error{
+		errors.New("db: connection refused"),
+		errors.New("db: connection refused"),
+		errors.New("nil pointer in pricing service"),
+	}
+	e.GET("/orders", func(c echo.Context) error {
+		err := errs[0]
+		errs = errs[1:]
+
+		return err
+	})
+	e.GET("/users", func(c echo.Context) error {
+		return echo.ErrForbidden
+	})
+
+	for i := 0; i < 3; i++ {
+		e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/orders", nil))
+	}
+
+	now = now.Add(time.Minute)
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users", nil))
+
+	var messages []string
+	for _, l := range strings.Split(strings.TrimSpace(b.String()), "\n") {
+		var line map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(l), &line))
+
+		messages = append(messages, fmt.Sprintf("%v: %v %v", line["message"], line["error"], line["repeated"]))
+	}
+
+	assert.Equal(t, []string{
+		"request returned an error: db: connection refused <nil>",
+		"request returned an error: nil pointer in pricing service <nil>",
+		"error repeated: db: connection refused 1",
+		"request returned an error: code=403, message=Forbidden <nil>",
+	}, messages)
+}
+
+func TestLogLimiter_fingerprint(t *testing.T) {
+	orderNotFound := &Code{ID: "ORDER_NOT_FOUND", Status: http.StatusNotFound}
+	orderLocked := &Code{ID: "ORDER_LOCKED", Status: http.StatusNotFound}
+
+	tests := []struct {
+		name     string
+		first    error
+		second   error
+		wantSame bool
+	}{
+		{
+			name:     "messages that only differ in numbers",
+			first:    errors.Wrap(errors.New("order 12 failed"), "calling db"),
+			second:   errors.Wrap(errors.New("order 13 failed"), "calling db"),
+			wantSame: true,
+		},
+		{
+			name:     "messages that only differ in IDs",
+			first:    errors.Errorf("user %s: session %s expired", "0b6e4b1c-7d5f-4c3e-9a37-5a0f0e8c2d11", "deadbeefcafe"),
+			second:   errors.Errorf("user %s: session %s expired", "f47ac10b-58cc-4372-a567-0e02b2c3d479", "0x7f3a"),
+			wantSame: true,
+		},
+		{
+			name:   "different messages of the same type",
+			first:  errors.New("db: connection refused"),
+			second: errors.New("nil pointer in pricing service"),
+		},
+		{
+			name:     "same code with different messages",
+			first:    orderNotFound.Wrap(nil).WithMessage("order 12 not found"),
+			second:   orderNotFound.Wrap(errors.New("sql: no rows in result set")).WithMessage("order 13 not found"),
+			wantSame: true,
+		},
+		{
+			name:   "different codes",
+			first:  orderNotFound.Wrap(nil),
+			second: orderLocked.Wrap(nil),
+		},
+		{
+			name:   "different kinds",
+			first:  NotFound(errors.New("order 12"), ""),
+			second: Conflict(errors.New("order 12"), ""),
+		},
+		{
+			name:   "different types",
+			first:  errors.New("order 12 failed"),
+			second: echo.NewHTTPError(http.StatusInternalServerError, "order 12 failed"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			first := fingerprint("/orders/:id", http.StatusNotFound, tt.first)
+			second := fingerprint("/orders/:id", http.StatusNotFound, tt.second)
+
+			assert.Equal(t, tt.wantSame, first == second)
+		})
+	}
+}
+
+func TestLogLimiter(t *testing.T) {
+	now := time.Date(2023, 8, 1, 12, 0, 0, 0, time.UTC)
+
+	ll := newLogLimiter(time.Minute)
+	ll.now = func() time.Time {
+		return now
+	}
+
+	err := errors.New("connection refused")
+
+	repeated, ok, _ := ll.allow("/orders", http.StatusInternalServerError, err)
+	assert.True(t, ok)
+	assert.Equal(t, 0, repeated)
+
+	for i := 0; i < 9999; i++ {
+		now = now.Add(time.Millisecond)
+
+		_, ok, _ = ll.allow("/orders", http.StatusInternalServerError, err)
+		require.False(t, ok)
+	}
+
+	repeated, ok, _ = ll.allow("/orders", http.StatusServiceUnavailable, err)
+	assert.True(t, ok, "different status should not be limited")
+	assert.Equal(t, 0, repeated)
+
+	now = now.Add(time.Minute)
+
+	repeated, ok, flushed := ll.allow("/orders", http.StatusInternalServerError, err)
+	assert.True(t, ok, "should be logged again after the window")
+	assert.Empty(t, flushed, "repeats are logged with the error")
+	assert.Equal(t, 9999, repeated)
+
+	now = now.Add(time.Second)
+
+	_, ok, _ = ll.allow("/orders", http.StatusInternalServerError, err)
+	assert.False(t, ok, "new window should have started")
+}
+
+func TestLogLimiter_flush(t *testing.T) {
+	now := time.Date(2023, 8, 1, 12, 0, 0, 0, time.UTC)
+
+	ll := newLogLimiter(time.Minute)
+	ll.now = func() time.Time {
+		return now
+	}
+
+	refused := errors.New("db: connection refused")
+	timeout := errors.New("db: timeout")
+
+	_, _, _ = ll.allow("/orders", http.StatusInternalServerError, refused)
+	_, _, _ = ll.allow("/orders", http.StatusInternalServerError, refused)
+	_, _, _ = ll.allow("/orders", http.StatusInternalServerError, refused)
+
+	now = now.Add(30 * time.Second)
+
+	_, ok, flushed := ll.allow("/users", http.StatusBadRequest, timeout)
+	assert.True(t, ok)
+	assert.Empty(t, flushed, "window should not have passed yet")
+
+	now = now.Add(30 * time.Second)
+
+	_, ok, flushed = ll.allow("/users", http.StatusBadRequest, timeout)
+	assert.False(t, ok)
+	require.Len(t, flushed, 1, "repeats should be flushed once the window passed")
+	assert.Equal(t, "/orders", flushed[0].route)
+	assert.Equal(t, http.StatusInternalServerError, flushed[0].status)
+	assert.Equal(t, "db: connection refused", flushed[0].message)
+	assert.Equal(t, 2, flushed[0].repeated)
+
+	repeated, ok, flushed := ll.allow("/orders", http.StatusInternalServerError, refused)
+	assert.True(t, ok)
+	assert.Equal(t, 0, repeated, "flushed repeats should not be logged again")
+	assert.Empty(t, flushed)
+}
+
+func TestLogLimiter_evict(t *testing.T) {
+	now := time.Date(2023, 8, 1, 12, 0, 0, 0, time.UTC)
+
+	ll := newLogLimiter(time.Minute)
+	ll.now = func() time.Time {
+		return now
+	}
+
+	for i := 0; i < maxFingerprints; i++ {
+		_, _, _ = ll.allow("/orders", i, nil)
+	}
+
+	_, ok, _ := ll.allow("/orders", -1, nil)
+	assert.True(t, ok)
+	assert.Len(t, ll.entries, maxFingerprints, "should not track more than the maximum")
+
+	now = now.Add(time.Minute)
+
+	_, ok, _ = ll.allow("/orders", -1, nil)
+	assert.True(t, ok)
+	assert.Len(t, ll.entries, 1, "expired entries should be evicted")
+}
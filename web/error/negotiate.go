@@ -0,0 +1,169 @@
+package error
+
+import (
+	"html/template"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+)
+
+// format is a representation of an error response the Handler can render.
+type format int
+
+const (
+	// formatDefault is the JSON shape the Handler is configured with, either the legacy body or problem details.
+	formatDefault format = iota
+
+	// formatProblem is RFC 7807 problem details, regardless of configuration.
+	formatProblem
+
+	// formatText is a single line of plain text.
+	formatText
+
+	// formatHTML is the configured HTML template.
+	formatHTML
+)
+
+// WithHTMLTemplate sets the template used to render errors for clients that prefer text/html, like browsers. The
+// template is executed with the *Problem as its data. If not set, those clients get JSON.
+func WithHTMLTemplate(t *template.Template) OptionModifier {
+	return func(o *HandlerOptions) {
+		o.htmlTemplate = t
+	}
+}
+
+// mediaRange is one entry of an Accept header.
+type mediaRange struct {
+	typ     string
+	subtype string
+	q       float64
+}
+
+// negotiate picks the format to render the error in based on the Accept header. Media ranges are tried in order of
+// their quality, and the first one that matches a format wins. JSON is the fallback for a missing header, wildcards, and
+// for media types the Handler can't render.
+func negotiate(accept string, opts HandlerOptions) format {
+	for _, mr := range parseAccept(accept) {
+		switch {
+		case mr.typ == "*" && mr.subtype == "*":
+			return formatDefault
+		case mr.typ == "application" && (mr.subtype == "json" || mr.subtype == "*"):
+			return formatDefault
+		case mr.typ == "application" && mr.subtype == "problem+json":
+			return formatProblem
+		case mr.typ == "text" && mr.subtype == "html" && opts.htmlTemplate != nil:
+			return formatHTML
+		case mr.typ == "text" && mr.subtype == "plain":
+			return formatText
+		case mr.typ == "text" && mr.subtype == "*":
+			if opts.htmlTemplate != nil {
+				return formatHTML
+			}
+
+			return formatText
+		}
+	}
+
+	return formatDefault
+}
+
+// parseAccept returns the media ranges in the Accept header, sorted by quality, highest first. Ranges with the same
+// quality keep the order they were sent in. Ranges with a quality of 0 and ones that can't be parsed are left out.
+func parseAccept(accept string) []mediaRange {
+	ranges := make([]mediaRange, 0)
+
+	for _, part := range strings.Split(accept, ",") {
+		mt, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		typ, subtype, ok := strings.Cut(mt, "/")
+		if !ok {
+			continue
+		}
+
+		q := 1.0
+		if qs, ok := params["q"]; ok {
+			q, err = strconv.ParseFloat(qs, 64)
+			if err != nil {
+				continue
+			}
+		}
+
+		if q <= 0 {
+			continue
+		}
+
+		ranges = append(ranges, mediaRange{typ: typ, subtype: subtype, q: q})
+	}
+
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].q > ranges[j].q
+	})
+
+	return ranges
+}
+
+// plainText is the text/plain representation of the Problem.
+func (p *Problem) plainText() string {
+	b := strings.Builder{}
+	b.WriteString(strconv.Itoa(p.Status))
+	b.WriteString(" ")
+	b.WriteString(p.Title)
+
+	if p.Detail != "" {
+		b.WriteString(": ")
+		b.WriteString(p.Detail)
+	}
+
+	b.WriteString("\n")
+
+	return b.String()
+}
+
+// render writes the Problem to the response in the negotiated format. If the HTML template fails to execute, the
+// default JSON shape is sent instead, and the template error is returned so it can be logged.
+//
+// Problem details and plain text can always be asked for, so the response varies by the Accept header, and caches are
+// told so.
+func render(res responder, r *http.Request, p *Problem, opts HandlerOptions) error {
+	f := negotiate(r.Header.Get(echo.HeaderAccept), opts)
+
+	res.header().Add(echo.HeaderVary, echo.HeaderAccept)
+
+	switch f {
+	case formatHTML:
+		b := strings.Builder{}
+
+		terr := opts.htmlTemplate.Execute(&b, p)
+		if terr == nil {
+			return res.html(p.Status, b.String())
+		}
+
+		if err := renderJSON(res, p, formatDefault, opts); err != nil {
+			return err
+		}
+
+		return errors.Wrap(terr, "opts.htmlTemplate.Execute")
+	case formatText:
+		return res.text(p.Status, p.plainText())
+	default:
+		return renderJSON(res, p, f, opts)
+	}
+}
+
+// renderJSON writes the Problem as JSON, either as problem details or as the legacy body.
+func renderJSON(res responder, p *Problem, f format, opts HandlerOptions) error {
+	if f == formatProblem || opts.problemDetails {
+		res.header().Set(echo.HeaderContentType, MIMEApplicationProblemJSON)
+		return res.json(p.Status, p)
+	}
+
+	return res.json(p.Status, p.legacyBody())
+}
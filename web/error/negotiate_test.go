@@ -0,0 +1,161 @@
+package error
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNegotiate(t *testing.T) {
+	withTemplate := HandlerOptions{htmlTemplate: template.Must(template.New("error").Parse(`{{.Title}}`))}
+
+	tests := []struct {
+		name   string
+		accept string
+		opts   HandlerOptions
+		want   format
+	}{
+		{
+			name:   "no accept header",
+			accept: "",
+			want:   formatDefault,
+		},
+		{
+			name:   "anything",
+			accept: "*/*",
+			want:   formatDefault,
+		},
+		{
+			name:   "json",
+			accept: "application/json",
+			want:   formatDefault,
+		},
+		{
+			name:   "problem json",
+			accept: "application/problem+json",
+			want:   formatProblem,
+		},
+		{
+			name:   "plain text",
+			accept: "text/plain",
+			want:   formatText,
+		},
+		{
+			name:   "html without a template falls back to json",
+			accept: "text/html",
+			want:   formatDefault,
+		},
+		{
+			name:   "browser",
+			accept: "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8",
+			opts:   withTemplate,
+			want:   formatHTML,
+		},
+		{
+			name:   "browser without a template",
+			accept: "text/html,application/xhtml+xml,application/xml;q=0.9,text/plain;q=0.8,*/*;q=0.7",
+			want:   formatText,
+		},
+		{
+			name:   "quality wins over order",
+			accept: "text/plain;q=0.5, application/problem+json",
+			want:   formatProblem,
+		},
+		{
+			name:   "zero quality is not acceptable",
+			accept: "text/plain;q=0, application/xml",
+			want:   formatDefault,
+		},
+		{
+			name:   "unparsable ranges are skipped",
+			accept: "garbage;;, text/plain",
+			want:   formatText,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, negotiate(tt.accept, tt.opts))
+		})
+	}
+}
+
+func TestHandler_contentNegotiation(t *testing.T) {
+	tmpl := template.Must(template.New("error").Parse(`<h1>{{.Status}} {{.Title}}</h1><p>{{.Detail}}</p>`))
+	broken := template.Must(template.New("error").Parse(`{{.Nope}}`))
+
+	tests := []struct {
+		name            string
+		options         []OptionModifier
+		accept          string
+		wantContentType string
+		wantBody        string
+	}{
+		{
+			name:            "json by default",
+			accept:          "",
+			wantContentType: echo.MIMEApplicationJSONCharsetUTF8,
+			wantBody: `{"message":"\u003cb\u003eorder\u003c/b\u003e not found","status":404}
+`,
+		},
+		{
+			name:            "problem json on request",
+			accept:          "application/problem+json",
+			wantContentType: MIMEApplicationProblemJSON,
+			wantBody: `{"detail":"\u003cb\u003eorder\u003c/b\u003e not found","instance":"unknown-request","status":404,"title":"Not Found","type":"about:blank"}
+`,
+		},
+		{
+			name:            "plain text",
+			accept:          "text/plain",
+			wantContentType: echo.MIMETextPlainCharsetUTF8,
+			wantBody:        "404 Not Found: <b>order</b> not found\n",
+		},
+		{
+			name:            "html",
+			options:         []OptionModifier{WithHTMLTemplate(tmpl)},
+			accept:          "text/html",
+			wantContentType: echo.MIMETextHTMLCharsetUTF8,
+			wantBody:        "<h1>404 Not Found</h1><p>&lt;b&gt;order&lt;/b&gt; not found</p>",
+		},
+		{
+			name:            "broken template falls back to json",
+			options:         []OptionModifier{WithHTMLTemplate(broken)},
+			accept:          "text/html",
+			wantContentType: echo.MIMEApplicationJSONCharsetUTF8,
+			wantBody: `{"message":"\u003cb\u003eorder\u003c/b\u003e not found","status":404}
+`,
+		},
+		{
+			name:            "unsupported type falls back to json",
+			accept:          "image/png",
+			wantContentType: echo.MIMEApplicationJSONCharsetUTF8,
+			wantBody: `{"message":"\u003cb\u003eorder\u003c/b\u003e not found","status":404}
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			e.HTTPErrorHandler = Handler(zerolog.Nop(), tt.options...)
+			e.GET("/", func(c echo.Context) error {
+				return NotFound(nil, "<b>order</b> not found")
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set(echo.HeaderAccept, tt.accept)
+			w := httptest.NewRecorder()
+
+			e.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusNotFound, w.Code)
+			assert.Equal(t, tt.wantContentType, w.Header().Get(echo.HeaderContentType))
+			assert.Contains(t, w.Header().Values(echo.HeaderVary), echo.HeaderAccept)
+			assert.Equal(t, tt.wantBody, w.Body.String())
+		})
+	}
+}
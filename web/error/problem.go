@@ -0,0 +1,163 @@
+package error
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+const (
+	// MIMEApplicationProblemJSON is the content type of RFC 7807 problem details responses.
+	MIMEApplicationProblemJSON = "application/problem+json"
+
+	// defaultProblemType is the type of problems that don't have anything else to say beyond the status code.
+	defaultProblemType = "about:blank"
+)
+
+// Problem is an RFC 7807 problem details object. Handlers can return one as an error, and the error Handler will send
+// it as is when it's configured with WithProblemDetails, or in the legacy {"status","message"} shape otherwise.
+//
+// Extensions are added to the top level of the JSON object, next to the standard members. Members with the same name
+// as one of the standard ones are ignored.
+//
+// If Instance is empty, the error Handler fills it in with the request ID.
+type Problem struct {
+	Type       string
+	Title      string
+	Status     int
+	Detail     string
+	Instance   string
+	Extensions map[string]interface{}
+
+	// Err is the underlying error. It's not sent to the client, but it's logged, and it's what Unwrap returns.
+	Err error
+
+	// message is the Message of the echo.HTTPError the problem was made from, if any. It's used for the legacy response
+	// shape, so those responses stay exactly as they were.
+	message interface{}
+
+	// kind is the Kind of the DomainError in the chain of the error the problem was resolved from, if any.
+	kind Kind
+
+	// messageKey is the key the detail is translated with, if it's not the detail itself.
+	messageKey string
+}
+
+// NewProblem returns a Problem with the passed in status code and detail, and the title set to the standard text of
+// the status code.
+func NewProblem(status int, detail string) *Problem {
+	return &Problem{
+		Type:   defaultProblemType,
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+	}
+}
+
+// Error implements the error interface.
+func (p *Problem) Error() string {
+	msg := p.Title
+	if p.Detail != "" {
+		msg = p.Title + ": " + p.Detail
+	}
+
+	if p.Err != nil {
+		return msg + ": " + p.Err.Error()
+	}
+
+	return msg
+}
+
+// Unwrap returns the underlying error.
+func (p *Problem) Unwrap() error {
+	return p.Err
+}
+
+// With adds an extension member to the problem, and returns the same problem so calls can be chained.
+func (p *Problem) With(key string, value interface{}) *Problem {
+	if p.Extensions == nil {
+		p.Extensions = make(map[string]interface{})
+	}
+
+	p.Extensions[key] = value
+
+	return p
+}
+
+// Wrap sets the underlying error of the problem, and returns the same problem so calls can be chained.
+func (p *Problem) Wrap(err error) *Problem {
+	p.Err = err
+
+	return p
+}
+
+// MarshalJSON implements json.Marshaler, flattening the extensions into the top level object.
+func (p *Problem) MarshalJSON() ([]byte, error) {
+	m := make(map[string]interface{}, len(p.Extensions)+5)
+	for k, v := range p.Extensions {
+		m[k] = v
+	}
+
+	m["type"] = p.Type
+	if p.Type == "" {
+		m["type"] = defaultProblemType
+	}
+
+	m["title"] = p.Title
+	if p.Title == "" {
+		m["title"] = http.StatusText(p.Status)
+	}
+
+	m["status"] = p.Status
+
+	if p.Detail != "" {
+		m["detail"] = p.Detail
+	}
+
+	if p.Instance != "" {
+		m["instance"] = p.Instance
+	}
+
+	return json.Marshal(m)
+}
+
+// clone returns a copy of the problem with its own extensions map, so the copy can be modified freely.
+func (p *Problem) clone() *Problem {
+	c := *p
+	c.Extensions = make(map[string]interface{}, len(p.Extensions))
+	for k, v := range p.Extensions {
+		c.Extensions[k] = v
+	}
+
+	return &c
+}
+
+// legacyBody returns the problem in the {"status","message"} shape that the error Handler responds with by default.
+// Extensions are added next to those two. If the problem was made from an echo.HTTPError with a message that is not a
+// string, that message is returned as is, the same way echo does it.
+func (p *Problem) legacyBody() interface{} {
+	message, ok := p.message.(string)
+	if !ok {
+		if p.message != nil {
+			return p.message
+		}
+
+		message = p.Detail
+		if message == "" {
+			message = p.Title
+		}
+
+		if message == "" {
+			message = http.StatusText(p.Status)
+		}
+	}
+
+	body := make(map[string]interface{}, len(p.Extensions)+2)
+	for k, v := range p.Extensions {
+		body[k] = v
+	}
+
+	body["status"] = p.Status
+	body["message"] = message
+
+	return body
+}
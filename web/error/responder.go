@@ -0,0 +1,102 @@
+package error
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+
+	kitHttp "github.com/suborbital/go-kit/web/http"
+)
+
+// responder sends the error response. It lets the echo and the net/http error handlers share everything but the
+// writing itself, so echo's handler keeps using echo's JSON serializer and response bookkeeping.
+type responder interface {
+	header() http.Header
+	status() (int, bool)
+	json(code int, body interface{}) error
+	text(code int, body string) error
+	html(code int, body string) error
+	noContent(code int) error
+}
+
+// echoResponder sends the response through the echo context.
+type echoResponder struct {
+	c echo.Context
+}
+
+func (er echoResponder) header() http.Header {
+	return er.c.Response().Header()
+}
+
+func (er echoResponder) status() (int, bool) {
+	return er.c.Response().Status, er.c.Response().Committed
+}
+
+func (er echoResponder) json(code int, body interface{}) error {
+	return er.c.JSON(code, body)
+}
+
+func (er echoResponder) text(code int, body string) error {
+	return er.c.String(code, body)
+}
+
+func (er echoResponder) html(code int, body string) error {
+	return er.c.HTML(code, body)
+}
+
+func (er echoResponder) noContent(code int) error {
+	return er.c.NoContent(code)
+}
+
+// httpResponder sends the response to an http.ResponseWriter, the same way echo would.
+type httpResponder struct {
+	w http.ResponseWriter
+}
+
+func (hr httpResponder) header() http.Header {
+	return hr.w.Header()
+}
+
+func (hr httpResponder) status() (int, bool) {
+	return kitHttp.ResponseStatus(hr.w)
+}
+
+func (hr httpResponder) json(code int, body interface{}) error {
+	hr.setContentType(echo.MIMEApplicationJSONCharsetUTF8)
+	hr.w.WriteHeader(code)
+
+	return errors.Wrap(json.NewEncoder(hr.w).Encode(body), "json.NewEncoder.Encode")
+}
+
+func (hr httpResponder) text(code int, body string) error {
+	return hr.blob(code, echo.MIMETextPlainCharsetUTF8, body)
+}
+
+func (hr httpResponder) html(code int, body string) error {
+	return hr.blob(code, echo.MIMETextHTMLCharsetUTF8, body)
+}
+
+func (hr httpResponder) noContent(code int) error {
+	hr.w.WriteHeader(code)
+
+	return nil
+}
+
+// blob writes the body with the content type, unless a content type has been set already.
+func (hr httpResponder) blob(code int, contentType, body string) error {
+	hr.setContentType(contentType)
+	hr.w.WriteHeader(code)
+
+	_, err := hr.w.Write([]byte(body))
+
+	return errors.Wrap(err, "w.Write")
+}
+
+// setContentType sets the content type header if it's not set yet, like echo does.
+func (hr httpResponder) setContentType(contentType string) {
+	if hr.w.Header().Get(echo.HeaderContentType) == "" {
+		hr.w.Header().Set(echo.HeaderContentType, contentType)
+	}
+}
@@ -0,0 +1,114 @@
+package error
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// HeaderRetryAfter tells the client how many seconds to wait before retrying.
+	HeaderRetryAfter = "Retry-After"
+
+	// HeaderRateLimitLimit is the request quota of the rate limit the client hit, as per the IETF RateLimit header
+	// fields draft.
+	HeaderRateLimitLimit = "RateLimit-Limit"
+
+	// HeaderRateLimitRemaining is the number of requests left in the quota.
+	HeaderRateLimitRemaining = "RateLimit-Remaining"
+
+	// HeaderRateLimitReset is the number of seconds until the quota resets.
+	HeaderRateLimitReset = "RateLimit-Reset"
+)
+
+// RateLimit is the state of the rate limit a request was rejected by.
+type RateLimit struct {
+	Limit     int
+	Remaining int
+	Reset     time.Duration
+}
+
+// RetryError carries the information a client needs to retry a request. It doesn't pick a status code by itself, it
+// wraps an error that does, like a DomainError of KindRateLimited or KindUnavailable, or an *echo.HTTPError. The Handler
+// sends the Retry-After and RateLimit-* headers when the error resolves to a 429 Too Many Requests or a 503 Service
+// Unavailable.
+type RetryError struct {
+	Err        error
+	RetryAfter time.Duration
+	RateLimit  *RateLimit
+}
+
+// Retry wraps the error with the duration the client should wait before retrying.
+func Retry(err error, after time.Duration) *RetryError {
+	return &RetryError{
+		Err:        err,
+		RetryAfter: after,
+	}
+}
+
+// WithRateLimit sets the rate limit state, and returns the same error so calls can be chained.
+func (e *RetryError) WithRateLimit(limit, remaining int, reset time.Duration) *RetryError {
+	e.RateLimit = &RateLimit{
+		Limit:     limit,
+		Remaining: remaining,
+		Reset:     reset,
+	}
+
+	return e
+}
+
+// Error returns the message of the wrapped error.
+func (e *RetryError) Error() string {
+	if e.Err == nil {
+		return "retry in " + e.RetryAfter.String()
+	}
+
+	return e.Err.Error()
+}
+
+// Unwrap returns the wrapped error.
+func (e *RetryError) Unwrap() error {
+	return e.Err
+}
+
+// WithDefaultRetryAfter sets the Retry-After header for 429 and 503 responses whose error doesn't carry its own retry
+// duration in a *RetryError.
+func WithDefaultRetryAfter(after time.Duration) OptionModifier {
+	return func(o *HandlerOptions) {
+		o.defaultRetryAfter = after
+	}
+}
+
+// setRetryHeaders sets the Retry-After and RateLimit-* headers if the status is 429 or 503.
+func setRetryHeaders(h http.Header, err error, status int, opts HandlerOptions) {
+	if status != http.StatusTooManyRequests && status != http.StatusServiceUnavailable {
+		return
+	}
+
+	after := opts.defaultRetryAfter
+
+	var re *RetryError
+	if errors.As(err, &re) {
+		if re.RetryAfter > 0 {
+			after = re.RetryAfter
+		}
+
+		if re.RateLimit != nil {
+			h.Set(HeaderRateLimitLimit, strconv.Itoa(re.RateLimit.Limit))
+			h.Set(HeaderRateLimitRemaining, strconv.Itoa(re.RateLimit.Remaining))
+			h.Set(HeaderRateLimitReset, seconds(re.RateLimit.Reset))
+		}
+	}
+
+	if after > 0 {
+		h.Set(HeaderRetryAfter, seconds(after))
+	}
+}
+
+// seconds returns the duration in whole seconds, rounded up, so clients don't come back too early.
+func seconds(d time.Duration) string {
+	return strconv.FormatInt(int64(math.Ceil(d.Seconds())), 10)
+}
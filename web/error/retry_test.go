@@ -0,0 +1,91 @@
+package error
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler_retryHeaders(t *testing.T) {
+	tests := []struct {
+		name        string
+		options     []OptionModifier
+		err         error
+		wantStatus  int
+		wantHeaders map[string]string
+	}{
+		{
+			name:       "rate limited with state",
+			err:        errors.Wrap(Retry(RateLimited(nil, "slow down"), 1500*time.Millisecond).WithRateLimit(100, 0, 30*time.Second), "checking quota"),
+			wantStatus: http.StatusTooManyRequests,
+			wantHeaders: map[string]string{
+				HeaderRetryAfter:         "2",
+				HeaderRateLimitLimit:     "100",
+				HeaderRateLimitRemaining: "0",
+				HeaderRateLimitReset:     "30",
+			},
+		},
+		{
+			name:       "unavailable inside an http error",
+			err:        echo.NewHTTPError(http.StatusServiceUnavailable).SetInternal(Retry(errors.New("db down"), time.Minute)),
+			wantStatus: http.StatusServiceUnavailable,
+			wantHeaders: map[string]string{
+				HeaderRetryAfter:         "60",
+				HeaderRateLimitLimit:     "",
+				HeaderRateLimitRemaining: "",
+				HeaderRateLimitReset:     "",
+			},
+		},
+		{
+			name:       "default retry after",
+			options:    []OptionModifier{WithDefaultRetryAfter(5 * time.Second)},
+			err:        echo.ErrTooManyRequests,
+			wantStatus: http.StatusTooManyRequests,
+			wantHeaders: map[string]string{
+				HeaderRetryAfter: "5",
+			},
+		},
+		{
+			name:       "no retry information",
+			err:        echo.ErrServiceUnavailable,
+			wantStatus: http.StatusServiceUnavailable,
+			wantHeaders: map[string]string{
+				HeaderRetryAfter: "",
+			},
+		},
+		{
+			name:       "other status codes don't get headers",
+			options:    []OptionModifier{WithDefaultRetryAfter(5 * time.Second)},
+			err:        Retry(errors.New("oh no"), time.Minute).WithRateLimit(100, 10, time.Minute),
+			wantStatus: http.StatusInternalServerError,
+			wantHeaders: map[string]string{
+				HeaderRetryAfter:     "",
+				HeaderRateLimitLimit: "",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			e.HTTPErrorHandler = Handler(zerolog.Nop(), tt.options...)
+			e.GET("/", func(c echo.Context) error {
+				return tt.err
+			})
+
+			w := httptest.NewRecorder()
+			e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+
+			for k, v := range tt.wantHeaders {
+				assert.Equal(t, v, w.Header().Get(k), k)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package error
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultStatusRegistry is the StatusRegistry the error Handler uses unless it's configured with WithStatusRegistry.
+// Services can register their own kinds and sentinel errors on it on startup.
+var DefaultStatusRegistry = NewStatusRegistry()
+
+// StatusRegistry maps error kinds and sentinel errors to http status codes. It's safe for concurrent use.
+type StatusRegistry struct {
+	mu        sync.RWMutex
+	kinds     map[Kind]int
+	sentinels []sentinelStatus
+}
+
+// sentinelStatus is a sentinel error and the status code it maps to.
+type sentinelStatus struct {
+	target error
+	status int
+}
+
+// NewStatusRegistry returns a StatusRegistry with the built-in kinds already registered:
+//   - KindNotFound - 404
+//   - KindConflict - 409
+//   - KindInvalid - 400
+//   - KindUnauthorized - 401
+//   - KindForbidden - 403
+//   - KindUnavailable - 503
+//   - KindRateLimited - 429
+func NewStatusRegistry() *StatusRegistry {
+	return &StatusRegistry{
+		kinds: map[Kind]int{
+			KindNotFound:     http.StatusNotFound,
+			KindConflict:     http.StatusConflict,
+			KindInvalid:      http.StatusBadRequest,
+			KindUnauthorized: http.StatusUnauthorized,
+			KindForbidden:    http.StatusForbidden,
+			KindUnavailable:  http.StatusServiceUnavailable,
+			KindRateLimited:  http.StatusTooManyRequests,
+		},
+	}
+}
+
+// RegisterKind maps the kind to the status code, replacing the previous mapping if there was one.
+func (r *StatusRegistry) RegisterKind(kind Kind, status int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.kinds[kind] = status
+}
+
+// RegisterError maps a sentinel error to the status code. Errors are matched with errors.Is, so the sentinel is found
+// anywhere in the chain of wrapped errors. Sentinels are checked in the order they were registered, and before kinds.
+func (r *StatusRegistry) RegisterError(target error, status int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.sentinels = append(r.sentinels, sentinelStatus{target: target, status: status})
+}
+
+// Status returns the status code for the error, and false if neither a registered sentinel error, nor a DomainError
+// with a registered kind is in its chain.
+func (r *StatusRegistry) Status(err error) (int, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, s := range r.sentinels {
+		if errors.Is(err, s.target) {
+			return s.status, true
+		}
+	}
+
+	var de *DomainError
+	if errors.As(err, &de) {
+		status, ok := r.kinds[de.Kind]
+		return status, ok
+	}
+
+	return 0, false
+}
@@ -0,0 +1,62 @@
+package error
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatusRegistry_Status(t *testing.T) {
+	errNoRows := errors.New("no rows")
+
+	r := NewStatusRegistry()
+	r.RegisterError(errNoRows, http.StatusNotFound)
+	r.RegisterKind(KindInvalid, http.StatusUnprocessableEntity)
+
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantOK     bool
+	}{
+		{
+			name:       "built-in kind",
+			err:        Forbidden(nil, "nope"),
+			wantStatus: http.StatusForbidden,
+			wantOK:     true,
+		},
+		{
+			name:       "overridden kind",
+			err:        Invalid(nil, "bad"),
+			wantStatus: http.StatusUnprocessableEntity,
+			wantOK:     true,
+		},
+		{
+			name:       "sentinel wins over kind",
+			err:        RateLimited(errors.Wrap(errNoRows, "query"), "slow down"),
+			wantStatus: http.StatusNotFound,
+			wantOK:     true,
+		},
+		{
+			name:   "unknown error",
+			err:    errors.New("whatever"),
+			wantOK: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, ok := r.Status(tt.err)
+
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.wantStatus, status)
+		})
+	}
+}
+
+func TestKindOf(t *testing.T) {
+	assert.Equal(t, KindNotFound, KindOf(errors.Wrap(NotFound(nil, "gone"), "looking")))
+	assert.Equal(t, KindUnknown, KindOf(errors.New("plain")))
+	assert.Equal(t, KindUnknown, KindOf(nil))
+}
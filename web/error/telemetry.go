@@ -0,0 +1,77 @@
+package error
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// instrumentationName is the name of the meter the error handler creates its instruments with.
+	instrumentationName = "github.com/suborbital/go-kit/web/error"
+
+	// errorCounterName is the name of the counter the error handler increments for every error.
+	errorCounterName = "http.server.errors"
+
+	// unknownKind is the value of the error kind attribute for errors that don't have a kind.
+	unknownKind = "unknown"
+)
+
+// spanRecordedError is implemented by errors that are already recorded on the span in the request context, like the
+// ones the Recover middleware of the web/mid package returns for panics.
+type spanRecordedError interface {
+	RecordedOnSpan() bool
+}
+
+// telemetry records the errors that reach the error handler on the active span, and in the error counter.
+type telemetry struct {
+	errors metric.Int64Counter
+}
+
+// newTelemetry creates the error counter on the global meter. If that fails, errors are still recorded on spans, but
+// not counted, and the error is returned so it can be logged.
+func newTelemetry() (telemetry, error) {
+	counter, err := otel.Meter(instrumentationName).Int64Counter(errorCounterName,
+		metric.WithDescription("Number of http requests that returned an error."),
+	)
+
+	return telemetry{errors: counter}, err
+}
+
+// record increments the error counter with the route, status code, and error kind as attributes. Server errors are also
+// recorded on the span in the context, and the span's status is set to error. Client errors are not, as per the
+// opentelemetry semantic conventions for server spans: a 4xx is the client's problem, not the server's. Errors that are
+// already recorded on the span, see spanRecordedError, are not recorded again.
+func (t telemetry) record(ctx context.Context, err error, route string, status int, kind Kind) {
+	if status >= http.StatusInternalServerError {
+		span := trace.SpanFromContext(ctx)
+
+		var sre spanRecordedError
+		if !errors.As(err, &sre) || !sre.RecordedOnSpan() {
+			span.RecordError(err)
+		}
+
+		span.SetStatus(codes.Error, http.StatusText(status))
+	}
+
+	if t.errors == nil {
+		return
+	}
+
+	k := string(kind)
+	if k == "" {
+		k = unknownKind
+	}
+
+	t.errors.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("http.route", route),
+		attribute.Int("http.status_code", status),
+		attribute.String("error.kind", k),
+	))
+}
@@ -0,0 +1,172 @@
+package error
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/suborbital/go-kit/web/mid"
+)
+
+func TestHandler_telemetry(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		wantSpanStatus codes.Code
+		wantAttributes attribute.Set
+	}{
+		{
+			name:           "client error leaves span alone",
+			err:            NotFound(nil, "order not found"),
+			wantSpanStatus: codes.Unset,
+			wantAttributes: attribute.NewSet(
+				attribute.String("http.route", "/orders/:id"),
+				attribute.Int("http.status_code", http.StatusNotFound),
+				attribute.String("error.kind", "not_found"),
+			),
+		},
+		{
+			name:           "server error marks span",
+			err:            errors.Wrap(Unavailable(errors.New("connection refused"), ""), "calling db"),
+			wantSpanStatus: codes.Error,
+			wantAttributes: attribute.NewSet(
+				attribute.String("http.route", "/orders/:id"),
+				attribute.Int("http.status_code", http.StatusServiceUnavailable),
+				attribute.String("error.kind", "unavailable"),
+			),
+		},
+		{
+			name:           "unknown error",
+			err:            errors.New("oh no"),
+			wantSpanStatus: codes.Error,
+			wantAttributes: attribute.NewSet(
+				attribute.String("http.route", "/orders/:id"),
+				attribute.Int("http.status_code", http.StatusInternalServerError),
+				attribute.String("error.kind", "unknown"),
+			),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader := sdkmetric.NewManualReader()
+			otel.SetMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+
+			spans := tracetest.NewSpanRecorder()
+			tp := trace.NewTracerProvider(trace.WithSpanProcessor(spans))
+
+			e := echo.New()
+			e.HTTPErrorHandler = Handler(zerolog.Nop())
+			e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+				return func(c echo.Context) error {
+					ctx, span := tp.Tracer("test").Start(c.Request().Context(), c.Path())
+					defer span.End()
+
+					c.SetRequest(c.Request().WithContext(ctx))
+
+					// the otelecho middleware also handles the error inside the span
+					err := next(c)
+					if err != nil {
+						c.Error(err)
+					}
+
+					return nil
+				}
+			})
+			e.GET("/orders/:id", func(c echo.Context) error {
+				return tt.err
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/orders/12", nil)
+			w := httptest.NewRecorder()
+
+			e.ServeHTTP(w, req)
+
+			ended := spans.Ended()
+			require.Len(t, ended, 1)
+			assert.Equal(t, tt.wantSpanStatus, ended[0].Status().Code)
+
+			if tt.wantSpanStatus == codes.Error {
+				assert.NotEmpty(t, ended[0].Events(), "error should be recorded on the span")
+			} else {
+				assert.Empty(t, ended[0].Events())
+			}
+
+			var rm metricdata.ResourceMetrics
+			require.NoError(t, reader.Collect(context.Background(), &rm))
+			require.Len(t, rm.ScopeMetrics, 1)
+			require.Len(t, rm.ScopeMetrics[0].Metrics, 1)
+
+			m := rm.ScopeMetrics[0].Metrics[0]
+			assert.Equal(t, "http.server.errors", m.Name)
+
+			sum, ok := m.Data.(metricdata.Sum[int64])
+			require.True(t, ok)
+			require.Len(t, sum.DataPoints, 1)
+			assert.Equal(t, int64(1), sum.DataPoints[0].Value)
+			assert.True(t, tt.wantAttributes.Equals(&sum.DataPoints[0].Attributes), "got attributes %v",
+				sum.DataPoints[0].Attributes.ToSlice())
+		})
+	}
+}
+
+func TestHandler_telemetryRecoveredPanic(t *testing.T) {
+	spans := tracetest.NewSpanRecorder()
+	tp := trace.NewTracerProvider(trace.WithSpanProcessor(spans))
+
+	e := echo.New()
+	e.HTTPErrorHandler = Handler(zerolog.Nop())
+	e.Use(
+		func(next echo.HandlerFunc) echo.HandlerFunc {
+			return func(c echo.Context) error {
+				ctx, span := tp.Tracer("test").Start(c.Request().Context(), c.Path())
+				defer span.End()
+
+				c.SetRequest(c.Request().WithContext(ctx))
+
+				err := next(c)
+				if err != nil {
+					c.Error(err)
+				}
+
+				return nil
+			}
+		},
+		mid.Recover(zerolog.Nop()),
+	)
+	e.GET("/", func(c echo.Context) error {
+		panic("boom")
+	})
+
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+
+	ended := spans.Ended()
+	require.Len(t, ended, 1)
+	assert.Equal(t, codes.Error, ended[0].Status().Code)
+	require.Len(t, ended[0].Events(), 1, "the panic should only be recorded by the Recover middleware")
+
+	hasStack := false
+	for _, kv := range ended[0].Events()[0].Attributes {
+		if kv.Key == "exception.stacktrace" {
+			hasStack = true
+		}
+	}
+
+	assert.True(t, hasStack, "the recorded panic should have the stack trace")
+}
@@ -0,0 +1,214 @@
+package error
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+)
+
+const (
+	// defaultValidationMessage is the message of a ValidationError that doesn't have one set.
+	defaultValidationMessage = "request validation failed"
+
+	// Rules used for errors that come from binding the request rather than from validation.
+	RuleType   = "type"
+	RuleSyntax = "syntax"
+	RuleBind   = "bind"
+
+	// RuleInvalid is used for validation errors that don't say which rule was broken.
+	RuleInvalid = "invalid"
+)
+
+// FieldError describes one invalid field of the request. Field is the path to the field, for example
+// "items[2].quantity", Rule is the name of the rule the value broke, for example "required", and Message is a human
+// readable description of the problem.
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// ValidationError collects every invalid field of a request, so the client can fix all of them at once. The error
+// Handler responds with the list of fields in an "errors" member.
+//
+// Status is optional, if it's zero the Handler uses the status configured with WithValidationStatus, which defaults
+// to 422 Unprocessable Entity.
+type ValidationError struct {
+	Message string
+	Status  int
+	Fields  []FieldError
+}
+
+// NewValidationError returns an empty ValidationError. Use Add to add invalid fields to it, and ErrorOrNil to return
+// it from a function only if there were any.
+func NewValidationError() *ValidationError {
+	return &ValidationError{}
+}
+
+// Add adds an invalid field to the error, and returns the same error so calls can be chained.
+func (v *ValidationError) Add(field, rule, message string) *ValidationError {
+	v.Fields = append(v.Fields, FieldError{
+		Field:   field,
+		Rule:    rule,
+		Message: message,
+	})
+
+	return v
+}
+
+// ErrorOrNil returns the ValidationError if it has at least one invalid field, and nil otherwise.
+func (v *ValidationError) ErrorOrNil() error {
+	if len(v.Fields) == 0 {
+		return nil
+	}
+
+	return v
+}
+
+// Error implements the error interface.
+func (v *ValidationError) Error() string {
+	msgs := make([]string, 0, len(v.Fields))
+	for _, f := range v.Fields {
+		if f.Field == "" {
+			msgs = append(msgs, f.Message)
+			continue
+		}
+
+		msgs = append(msgs, f.Field+": "+f.Message)
+	}
+
+	return v.message() + ": " + strings.Join(msgs, "; ")
+}
+
+// message returns the message of the error, or the default one if it's not set.
+func (v *ValidationError) message() string {
+	if v.Message == "" {
+		return defaultValidationMessage
+	}
+
+	return v.Message
+}
+
+// BindAndValidate binds the request into i with c.Bind, and then validates it with c.Validate. Errors of both are
+// turned into a *ValidationError with FromBindError and FromValidateError, so the client gets the same shape of
+// response either way.
+func BindAndValidate(c echo.Context, i interface{}) error {
+	if err := c.Bind(i); err != nil {
+		return FromBindError(err)
+	}
+
+	if err := c.Validate(i); err != nil {
+		return FromValidateError(err)
+	}
+
+	return nil
+}
+
+// FromBindError turns an error returned by echo's c.Bind into a *ValidationError with a 400 Bad Request status.
+// Binding errors that aren't about the content of the request, for example an unsupported media type, are returned
+// unchanged.
+func FromBindError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var be *echo.BindingError
+	if errors.As(err, &be) {
+		return &ValidationError{
+			Status: http.StatusBadRequest,
+			Fields: []FieldError{{Field: be.Field, Rule: RuleType, Message: fmt.Sprint(be.Message)}},
+		}
+	}
+
+	var he *echo.HTTPError
+	if !errors.As(err, &he) || he.Code != http.StatusBadRequest {
+		return err
+	}
+
+	ve := &ValidationError{Status: http.StatusBadRequest}
+
+	var ute *json.UnmarshalTypeError
+	var se *json.SyntaxError
+
+	switch {
+	case errors.As(he.Internal, &ute):
+		ve.Add(ute.Field, RuleType, "must be of type "+ute.Type.String())
+	case errors.As(he.Internal, &se), errors.Is(he.Internal, io.ErrUnexpectedEOF):
+		ve.Add("", RuleSyntax, "request body is not valid JSON")
+	default:
+		ve.Add("", RuleBind, fmt.Sprint(he.Message))
+	}
+
+	return ve
+}
+
+// fieldError is the shape of the individual errors that struct tag validators like go-playground/validator return.
+type fieldError interface {
+	Field() string
+	Tag() string
+	Error() string
+}
+
+// namespacedFieldError is a fieldError that also knows the full path to the field.
+type namespacedFieldError interface {
+	Namespace() string
+}
+
+// FromValidateError turns an error returned by echo's c.Validate into a *ValidationError. It understands:
+//   - a *ValidationError, which is returned as is
+//   - a slice of errors that have Field, Tag and Error methods, which is what struct tag validators like
+//     go-playground/validator return
+//   - an *echo.HTTPError, or echo.ErrValidatorNotRegistered, which are returned unchanged, as they are not the client's
+//     fault
+//
+// Anything else is turned into a ValidationError with a single entry without a field.
+func FromValidateError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var ve *ValidationError
+	if errors.As(err, &ve) {
+		return ve
+	}
+
+	var he *echo.HTTPError
+	if errors.As(err, &he) || errors.Is(err, echo.ErrValidatorNotRegistered) {
+		return err
+	}
+
+	ve = NewValidationError()
+
+	rv := reflect.ValueOf(err)
+	if rv.Kind() == reflect.Slice {
+		for i := 0; i < rv.Len(); i++ {
+			fe, ok := rv.Index(i).Interface().(fieldError)
+			if !ok {
+				continue
+			}
+
+			field := fe.Field()
+			if nfe, ok := fe.(namespacedFieldError); ok && nfe.Namespace() != "" {
+				// The namespace starts with the name of the top level struct, which means nothing to the client.
+				ns := nfe.Namespace()
+				if i := strings.Index(ns, "."); i >= 0 {
+					field = ns[i+1:]
+				}
+			}
+
+			ve.Add(field, fe.Tag(), fe.Error())
+		}
+	}
+
+	if len(ve.Fields) == 0 {
+		ve.Add("", RuleInvalid, err.Error())
+	}
+
+	return ve
+}
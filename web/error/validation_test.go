@@ -0,0 +1,187 @@
+package error
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockFieldError looks like the errors go-playground/validator returns.
+type mockFieldError struct {
+	namespace, field, tag string
+}
+
+func (m mockFieldError) Namespace() string { return m.namespace }
+func (m mockFieldError) Field() string     { return m.field }
+func (m mockFieldError) Tag() string       { return m.tag }
+func (m mockFieldError) Error() string     { return m.field + " failed on " + m.tag }
+
+type mockValidationErrors []mockFieldError
+
+func (m mockValidationErrors) Error() string { return "validation failed" }
+
+// mockValidator always fails with the configured error.
+type mockValidator struct {
+	err error
+}
+
+func (m mockValidator) Validate(interface{}) error { return m.err }
+
+func TestBindAndValidate(t *testing.T) {
+	type request struct {
+		Name     string `json:"name"`
+		Quantity int    `json:"quantity"`
+		Page     int    `query:"page"`
+	}
+
+	tests := []struct {
+		name        string
+		validator   echo.Validator
+		target      string
+		contentType string
+		body        string
+		wantStatus  int
+		wantBody    string
+	}{
+		{
+			name:        "wrong type in json body",
+			target:      "/",
+			contentType: echo.MIMEApplicationJSON,
+			body:        `{"name":"cake","quantity":"lots"}`,
+			wantStatus:  http.StatusBadRequest,
+			wantBody: `{"status":400,"message":"request validation failed","errors":[` +
+				`{"field":"quantity","rule":"type","message":"must be of type int"}]}`,
+		},
+		{
+			name:        "malformed json body",
+			target:      "/",
+			contentType: echo.MIMEApplicationJSON,
+			body:        `{"name":`,
+			wantStatus:  http.StatusBadRequest,
+			wantBody: `{"status":400,"message":"request validation failed","errors":[` +
+				`{"field":"","rule":"syntax","message":"request body is not valid JSON"}]}`,
+		},
+		{
+			name:        "unsupported media type is not a validation error",
+			target:      "/",
+			contentType: "application/cake",
+			body:        `cake`,
+			wantStatus:  http.StatusUnsupportedMediaType,
+			wantBody:    `{"status":415,"message":"Unsupported Media Type"}`,
+		},
+		{
+			name: "struct tag validator errors",
+			validator: mockValidator{err: mockValidationErrors{
+				{namespace: "request.Name", field: "Name", tag: "required"},
+				{namespace: "request.Items[1].Quantity", field: "Quantity", tag: "min"},
+			}},
+			target:      "/",
+			contentType: echo.MIMEApplicationJSON,
+			body:        `{}`,
+			wantStatus:  http.StatusUnprocessableEntity,
+			wantBody: `{"status":422,"message":"request validation failed","errors":[` +
+				`{"field":"Name","rule":"required","message":"Name failed on required"},` +
+				`{"field":"Items[1].Quantity","rule":"min","message":"Quantity failed on min"}]}`,
+		},
+		{
+			name: "validation error from validator",
+			validator: mockValidator{
+				err: NewValidationError().Add("name", "required", "name is required"),
+			},
+			target:      "/",
+			contentType: echo.MIMEApplicationJSON,
+			body:        `{}`,
+			wantStatus:  http.StatusUnprocessableEntity,
+			wantBody: `{"status":422,"message":"request validation failed","errors":[` +
+				`{"field":"name","rule":"required","message":"name is required"}]}`,
+		},
+		{
+			name:        "plain error from validator",
+			validator:   mockValidator{err: errors.New("name is too boring")},
+			target:      "/",
+			contentType: echo.MIMEApplicationJSON,
+			body:        `{}`,
+			wantStatus:  http.StatusUnprocessableEntity,
+			wantBody: `{"status":422,"message":"request validation failed","errors":[` +
+				`{"field":"","rule":"invalid","message":"name is too boring"}]}`,
+		},
+		{
+			name:        "no validator registered",
+			target:      "/",
+			contentType: echo.MIMEApplicationJSON,
+			body:        `{}`,
+			wantStatus:  http.StatusInternalServerError,
+			wantBody:    `{"status":500,"message":"Internal Server Error"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			e.Validator = tt.validator
+			e.HTTPErrorHandler = Handler(zerolog.Nop())
+			e.POST("/", func(c echo.Context) error {
+				var req request
+				if err := BindAndValidate(c, &req); err != nil {
+					return errors.Wrap(err, "BindAndValidate")
+				}
+
+				return c.NoContent(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodPost, tt.target, strings.NewReader(tt.body))
+			req.Header.Set(echo.HeaderContentType, tt.contentType)
+			w := httptest.NewRecorder()
+
+			e.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Result().StatusCode)
+			assert.JSONEq(t, tt.wantBody, w.Body.String())
+		})
+	}
+}
+
+func TestFromBindError_bindingError(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/?page=two", nil)
+	c := e.NewContext(req, httptest.NewRecorder())
+
+	var page int
+	err := echo.QueryParamsBinder(c).Int("page", &page).BindError()
+	require.Error(t, err)
+
+	var ve *ValidationError
+	require.ErrorAs(t, FromBindError(err), &ve)
+	assert.Equal(t, http.StatusBadRequest, ve.Status)
+	require.Len(t, ve.Fields, 1)
+	assert.Equal(t, "page", ve.Fields[0].Field)
+	assert.Equal(t, RuleType, ve.Fields[0].Rule)
+}
+
+func TestHandler_validationProblemDetails(t *testing.T) {
+	e := echo.New()
+	e.HTTPErrorHandler = Handler(zerolog.Nop(), WithProblemDetails(), WithValidationStatus(http.StatusBadRequest))
+	e.GET("/", func(c echo.Context) error {
+		return NewValidationError().
+			Add("email", "email", "must be an email address").
+			Add("age", "min", "must be at least 18").
+			ErrorOrNil()
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	w := httptest.NewRecorder()
+
+	e.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Result().StatusCode)
+	assert.JSONEq(t, `{"type":"about:blank","title":"Bad Request","status":400,`+
+		`"detail":"request validation failed","instance":"unknown-request","errors":[`+
+		`{"field":"email","rule":"email","message":"must be an email address"},`+
+		`{"field":"age","rule":"min","message":"must be at least 18"}]}`, w.Body.String())
+}
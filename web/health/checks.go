@@ -0,0 +1,47 @@
+package health
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+)
+
+// GRPCConnCheck checks the state of a grpc connection, like the one to the opentelemetry collector. It passes when the
+// connection is ready, connecting, or idle, in which case it also starts connecting, so the next probe knows more.
+// Connecting is a normal state of a healthy connection, after it's been idle, or after the other end restarted. It
+// fails when the connection is failing, or shut down. Register it with NonCritical if the service can do without the
+// other end for a while:
+//
+//	checker.MustRegister("otel-collector", health.GRPCConnCheck(grpcConn), health.NonCritical())
+func GRPCConnCheck(conn *grpc.ClientConn) Check {
+	return func(_ context.Context) error {
+		switch state := conn.GetState(); state {
+		case connectivity.TransientFailure, connectivity.Shutdown:
+			return errors.Errorf("grpc connection to %s is %s", conn.Target(), state)
+		case connectivity.Idle:
+			conn.Connect()
+			return nil
+		default:
+			return nil
+		}
+	}
+}
+
+// ShutdownCheck fails while the server isn't ready, which is before it started, and once it started shutting down.
+// Pass it the same atomic.Bool as server.Config's Ready, so the readiness probe fails during the drain period:
+//
+//	var ready atomic.Bool
+//	checker.MustRegister("server", health.ShutdownCheck(&ready))
+//	server.Run(ctx, server.Config{Ready: &ready, ...})
+func ShutdownCheck(ready *atomic.Bool) Check {
+	return func(_ context.Context) error {
+		if !ready.Load() {
+			return errors.New("server is starting or shutting down")
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,92 @@
+package health_test
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/suborbital/go-kit/web/health"
+)
+
+func TestGRPCConnCheck(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	srv := grpc.NewServer()
+	defer srv.Stop()
+
+	go func() {
+		_ = srv.Serve(ln)
+	}()
+
+	conn, err := grpc.Dial(ln.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+
+	check := health.GRPCConnCheck(conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	for state := conn.GetState(); state != connectivity.Ready; state = conn.GetState() {
+		require.True(t, conn.WaitForStateChange(ctx, state), "connection didn't become ready")
+	}
+
+	assert.NoError(t, check(context.Background()))
+
+	require.NoError(t, conn.Close())
+
+	assert.ErrorContains(t, check(context.Background()), "is SHUTDOWN")
+}
+
+func TestGRPCConnCheck_idleToConnecting(t *testing.T) {
+	// the listener never answers the grpc handshake, so the connection stays connecting once it leaves idle.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	defer ln.Close()
+
+	conn, err := grpc.Dial(ln.Addr().String(),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithIdleTimeout(50*time.Millisecond),
+	)
+	require.NoError(t, err)
+
+	defer conn.Close()
+
+	check := health.GRPCConnCheck(conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	for state := conn.GetState(); state != connectivity.Idle; state = conn.GetState() {
+		require.True(t, conn.WaitForStateChange(ctx, state), "connection didn't become idle")
+	}
+
+	assert.NoError(t, check(context.Background()))
+
+	require.True(t, conn.WaitForStateChange(ctx, connectivity.Idle), "the check should start connecting")
+	assert.Equal(t, connectivity.Connecting, conn.GetState())
+	assert.NoError(t, check(context.Background()))
+}
+
+func TestShutdownCheck(t *testing.T) {
+	var ready atomic.Bool
+
+	check := health.ShutdownCheck(&ready)
+
+	assert.EqualError(t, check(context.Background()), "server is starting or shutting down")
+
+	ready.Store(true)
+	assert.NoError(t, check(context.Background()))
+
+	ready.Store(false)
+	assert.Error(t, check(context.Background()))
+}
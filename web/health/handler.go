@@ -0,0 +1,47 @@
+package health
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Handler returns an echo handler that runs the checks of the probe, and responds with the Report as JSON. The status
+// code is 503 Service Unavailable if the report's status is fail, and 200 OK otherwise, including when a non-critical
+// check failed. Serve the probes on /livez, /readyz and /healthz:
+//
+//	e.GET("/livez", checker.Handler(health.Liveness))
+//	e.GET("/readyz", checker.Handler(health.Readiness))
+//	e.GET("/healthz", checker.Handler(health.Health))
+func (c *Checker) Handler(probe Probe) echo.HandlerFunc {
+	return func(ec echo.Context) error {
+		report := c.Run(ec.Request().Context(), probe)
+
+		ec.Response().Header().Set(echo.HeaderCacheControl, "no-store")
+
+		return ec.JSON(report.statusCode(), report)
+	}
+}
+
+// HTTPHandler is the net/http equivalent of Handler.
+func (c *Checker) HTTPHandler(probe Probe) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		report := c.Run(r.Context(), probe)
+
+		w.Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		w.Header().Set(echo.HeaderCacheControl, "no-store")
+		w.WriteHeader(report.statusCode())
+
+		_ = json.NewEncoder(w).Encode(report)
+	}
+}
+
+// statusCode returns the status code of the response for the report.
+func (r Report) statusCode() int {
+	if r.Status == StatusFail {
+		return http.StatusServiceUnavailable
+	}
+
+	return http.StatusOK
+}
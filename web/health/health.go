@@ -0,0 +1,274 @@
+package health
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// defaultTimeout is how long a check gets, unless it's registered with WithTimeout.
+	defaultTimeout = 5 * time.Second
+)
+
+// Statuses of a check, and of a Report.
+const (
+	StatusPass = "pass"
+	StatusWarn = "warn"
+	StatusFail = "fail"
+)
+
+// Probe is the kind of question a health endpoint answers.
+type Probe int
+
+const (
+	// Liveness asks whether the process works at all, or should be restarted. Only the checks registered with
+	// WithLiveness are run for it.
+	Liveness Probe = iota
+
+	// Readiness asks whether the process can serve requests. Every check is run for it.
+	Readiness
+
+	// Health asks for the state of everything, for people and dashboards. Every check is run for it.
+	Health
+)
+
+// Check checks one dependency or part of the service. It returns nil if it's healthy, and an error that says what's
+// wrong otherwise. The context is canceled when the check's timeout is over.
+type Check func(ctx context.Context) error
+
+// CheckOptions represents configuration options for a registered check.
+type CheckOptions struct {
+	timeout  time.Duration
+	critical bool
+	cacheTTL time.Duration
+	liveness bool
+}
+
+// OptionModifier is a type of function that changes values on a CheckOptions struct in place.
+type OptionModifier func(o *CheckOptions)
+
+// WithTimeout sets how long the check gets before it counts as failed. If not set, it gets 5 seconds.
+func WithTimeout(timeout time.Duration) OptionModifier {
+	return func(o *CheckOptions) {
+		o.timeout = timeout
+	}
+}
+
+// NonCritical makes a failure of the check a warning, which is reported, but doesn't make the probe fail. Use it for
+// dependencies the service can do without for a while, like the telemetry collector.
+func NonCritical() OptionModifier {
+	return func(o *CheckOptions) {
+		o.critical = false
+	}
+}
+
+// WithCacheTTL reuses the result of the check for the duration, so expensive checks don't run on every probe.
+func WithCacheTTL(ttl time.Duration) OptionModifier {
+	return func(o *CheckOptions) {
+		o.cacheTTL = ttl
+	}
+}
+
+// WithLiveness runs the check for the liveness probe too. Only use it for checks whose failure means the process has
+// to be restarted, a failing dependency shouldn't restart every service that depends on it.
+func WithLiveness() OptionModifier {
+	return func(o *CheckOptions) {
+		o.liveness = true
+	}
+}
+
+// Result is the outcome of one check.
+type Result struct {
+	Status    string    `json:"status"`
+	Critical  bool      `json:"critical"`
+	Error     string    `json:"error,omitempty"`
+	Duration  float64   `json:"durationMs"`
+	CheckedAt time.Time `json:"checkedAt"`
+	Cached    bool      `json:"cached,omitempty"`
+}
+
+// Report is the outcome of a probe. Status is fail if a critical check failed, warn if a non-critical one did, and pass
+// otherwise.
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks"`
+}
+
+// Checker holds the registered checks, and runs them for the probes. The zero value is not usable, create one with
+// New.
+type Checker struct {
+	mu     sync.RWMutex
+	checks map[string]*registeredCheck
+}
+
+// New returns a Checker without any checks. Probes pass until checks are registered.
+func New() *Checker {
+	return &Checker{
+		checks: make(map[string]*registeredCheck),
+	}
+}
+
+// Register adds a named check. Checks are critical, only run for the readiness and health probes, not cached, and get
+// 5 seconds by default, which the options change. It returns an error if there's a check with the same name already.
+func (c *Checker) Register(name string, check Check, options ...OptionModifier) error {
+	opts := CheckOptions{
+		timeout:  defaultTimeout,
+		critical: true,
+	}
+
+	for _, option := range options {
+		option(&opts)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.checks[name]; ok {
+		return errors.Errorf("health: a check named %q is already registered", name)
+	}
+
+	c.checks[name] = &registeredCheck{
+		check: check,
+		opts:  opts,
+	}
+
+	return nil
+}
+
+// MustRegister is Register that panics if the check can't be registered.
+func (c *Checker) MustRegister(name string, check Check, options ...OptionModifier) {
+	if err := c.Register(name, check, options...); err != nil {
+		panic(err)
+	}
+}
+
+// Run runs the checks of the probe concurrently, and returns their results.
+func (c *Checker) Run(ctx context.Context, probe Probe) Report {
+	c.mu.RLock()
+	names := make([]string, 0, len(c.checks))
+	for name, rc := range c.checks {
+		if probe == Liveness && !rc.opts.liveness {
+			continue
+		}
+
+		names = append(names, name)
+	}
+	checks := c.checks
+	c.mu.RUnlock()
+
+	sort.Strings(names)
+
+	results := make([]Result, len(names))
+
+	var wg sync.WaitGroup
+
+	for i, name := range names {
+		wg.Add(1)
+
+		go func(i int, rc *registeredCheck) {
+			defer wg.Done()
+
+			results[i] = rc.run(ctx)
+		}(i, checks[name])
+	}
+
+	wg.Wait()
+
+	report := Report{
+		Status: StatusPass,
+		Checks: make(map[string]Result, len(names)),
+	}
+
+	for i, name := range names {
+		r := results[i]
+		report.Checks[name] = r
+
+		switch {
+		case r.Status == StatusFail && r.Critical:
+			report.Status = StatusFail
+		case r.Status != StatusPass && report.Status == StatusPass:
+			report.Status = StatusWarn
+		}
+	}
+
+	return report
+}
+
+// registeredCheck is a check with its options, and its cached result.
+type registeredCheck struct {
+	check Check
+	opts  CheckOptions
+
+	mu     sync.Mutex
+	cached Result
+}
+
+// run runs the check, or returns its cached result if it's still fresh.
+func (rc *registeredCheck) run(ctx context.Context) Result {
+	if rc.opts.cacheTTL > 0 {
+		rc.mu.Lock()
+		defer rc.mu.Unlock()
+
+		if !rc.cached.CheckedAt.IsZero() && time.Since(rc.cached.CheckedAt) < rc.opts.cacheTTL {
+			r := rc.cached
+			r.Cached = true
+
+			return r
+		}
+	}
+
+	start := time.Now()
+	err := rc.runWithTimeout(ctx)
+
+	r := Result{
+		Status:    StatusPass,
+		Critical:  rc.opts.critical,
+		Duration:  float64(time.Since(start)) / float64(time.Millisecond),
+		CheckedAt: start,
+	}
+
+	if err != nil {
+		r.Status = StatusFail
+		if !rc.opts.critical {
+			r.Status = StatusWarn
+		}
+
+		r.Error = err.Error()
+	}
+
+	if rc.opts.cacheTTL > 0 {
+		rc.cached = r
+	}
+
+	return r
+}
+
+// runWithTimeout runs the check, and stops waiting for it when the timeout is over. A check that doesn't respect the
+// context keeps running in the background until it returns.
+func (rc *registeredCheck) runWithTimeout(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, rc.opts.timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				done <- errors.Errorf("check panicked: %v", p)
+			}
+		}()
+
+		done <- rc.check(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return errors.Wrap(ctx.Err(), "check didn't finish in time")
+	}
+}
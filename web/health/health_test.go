@@ -0,0 +1,227 @@
+package health_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/suborbital/go-kit/web/health"
+)
+
+func pass(context.Context) error {
+	return nil
+}
+
+func fail(context.Context) error {
+	return errors.New("connection refused")
+}
+
+func TestChecker_Run(t *testing.T) {
+	type check struct {
+		name    string
+		check   health.Check
+		options []health.OptionModifier
+	}
+
+	tests := []struct {
+		name       string
+		checks     []check
+		probe      health.Probe
+		wantStatus string
+		wantChecks map[string]string
+	}{
+		{
+			name:       "no checks",
+			probe:      health.Readiness,
+			wantStatus: health.StatusPass,
+			wantChecks: map[string]string{},
+		},
+		{
+			name: "all pass",
+			checks: []check{
+				{name: "db", check: pass},
+				{name: "cache", check: pass},
+			},
+			probe:      health.Readiness,
+			wantStatus: health.StatusPass,
+			wantChecks: map[string]string{"db": health.StatusPass, "cache": health.StatusPass},
+		},
+		{
+			name: "critical check fails",
+			checks: []check{
+				{name: "db", check: fail},
+				{name: "cache", check: pass},
+			},
+			probe:      health.Health,
+			wantStatus: health.StatusFail,
+			wantChecks: map[string]string{"db": health.StatusFail, "cache": health.StatusPass},
+		},
+		{
+			name: "non-critical check fails",
+			checks: []check{
+				{name: "db", check: pass},
+				{name: "collector", check: fail, options: []health.OptionModifier{health.NonCritical()}},
+			},
+			probe:      health.Readiness,
+			wantStatus: health.StatusWarn,
+			wantChecks: map[string]string{"db": health.StatusPass, "collector": health.StatusWarn},
+		},
+		{
+			name: "liveness only runs liveness checks",
+			checks: []check{
+				{name: "db", check: fail},
+				{name: "deadlock", check: pass, options: []health.OptionModifier{health.WithLiveness()}},
+			},
+			probe:      health.Liveness,
+			wantStatus: health.StatusPass,
+			wantChecks: map[string]string{"deadlock": health.StatusPass},
+		},
+		{
+			name: "timeout",
+			checks: []check{
+				{
+					name: "slow",
+					check: func(ctx context.Context) error {
+						<-ctx.Done()
+						return nil
+					},
+					options: []health.OptionModifier{health.WithTimeout(10 * time.Millisecond)},
+				},
+			},
+			probe:      health.Readiness,
+			wantStatus: health.StatusFail,
+			wantChecks: map[string]string{"slow": health.StatusFail},
+		},
+		{
+			name: "panic",
+			checks: []check{
+				{
+					name: "broken",
+					check: func(context.Context) error {
+						panic("boom")
+					},
+				},
+			},
+			probe:      health.Readiness,
+			wantStatus: health.StatusFail,
+			wantChecks: map[string]string{"broken": health.StatusFail},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := health.New()
+			for _, ch := range tt.checks {
+				require.NoError(t, c.Register(ch.name, ch.check, ch.options...))
+			}
+
+			report := c.Run(context.Background(), tt.probe)
+
+			assert.Equal(t, tt.wantStatus, report.Status)
+
+			gotChecks := make(map[string]string, len(report.Checks))
+			for name, r := range report.Checks {
+				gotChecks[name] = r.Status
+			}
+
+			assert.Equal(t, tt.wantChecks, gotChecks)
+		})
+	}
+}
+
+func TestChecker_Register_duplicate(t *testing.T) {
+	c := health.New()
+	require.NoError(t, c.Register("db", pass))
+
+	assert.EqualError(t, c.Register("db", pass), `health: a check named "db" is already registered`)
+	assert.Panics(t, func() {
+		c.MustRegister("db", pass)
+	})
+}
+
+func TestChecker_Run_cache(t *testing.T) {
+	var calls atomic.Int32
+
+	c := health.New()
+	c.MustRegister("db", func(context.Context) error {
+		calls.Add(1)
+		return nil
+	}, health.WithCacheTTL(time.Hour))
+
+	first := c.Run(context.Background(), health.Readiness)
+	second := c.Run(context.Background(), health.Readiness)
+
+	assert.Equal(t, int32(1), calls.Load())
+	assert.False(t, first.Checks["db"].Cached)
+	assert.True(t, second.Checks["db"].Cached)
+	assert.Equal(t, first.Checks["db"].CheckedAt, second.Checks["db"].CheckedAt)
+}
+
+func TestChecker_handlers(t *testing.T) {
+	c := health.New()
+	c.MustRegister("db", fail)
+	c.MustRegister("deadlock", pass, health.WithLiveness())
+
+	e := echo.New()
+	e.GET("/livez", c.Handler(health.Liveness))
+	e.GET("/readyz", c.Handler(health.Readiness))
+
+	mux := http.NewServeMux()
+	mux.Handle("/livez", c.HTTPHandler(health.Liveness))
+	mux.Handle("/readyz", c.HTTPHandler(health.Readiness))
+
+	tests := []struct {
+		name       string
+		target     string
+		wantStatus int
+		wantChecks []string
+	}{
+		{
+			name:       "live",
+			target:     "/livez",
+			wantStatus: http.StatusOK,
+			wantChecks: []string{"deadlock"},
+		},
+		{
+			name:       "not ready",
+			target:     "/readyz",
+			wantStatus: http.StatusServiceUnavailable,
+			wantChecks: []string{"db", "deadlock"},
+		},
+	}
+	for _, tt := range tests {
+		for server, h := range map[string]http.Handler{"echo": e, "net/http": mux} {
+			t.Run(tt.name+" with "+server, func(t *testing.T) {
+				w := httptest.NewRecorder()
+				h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.target, nil))
+
+				assert.Equal(t, tt.wantStatus, w.Code)
+				assert.Equal(t, "no-store", w.Header().Get(echo.HeaderCacheControl))
+				assert.Contains(t, w.Header().Get(echo.HeaderContentType), echo.MIMEApplicationJSON)
+
+				var report health.Report
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+
+				gotChecks := make([]string, 0, len(report.Checks))
+				for name := range report.Checks {
+					gotChecks = append(gotChecks, name)
+				}
+
+				assert.ElementsMatch(t, tt.wantChecks, gotChecks)
+
+				if db, ok := report.Checks["db"]; ok {
+					assert.Equal(t, "connection refused", db.Error)
+					assert.True(t, db.Critical)
+				}
+			})
+		}
+	}
+}
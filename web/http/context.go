@@ -2,6 +2,7 @@ package http
 
 import (
 	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog"
 )
 
 const noRequestID = "unknown-request"
@@ -33,3 +34,14 @@ func RID(c echo.Context) string {
 
 	return rid
 }
+
+// RequestLogger returns the request scoped logger stored in the request context by the mid.ContextLogger middleware.
+// If there isn't one, a disabled logger is returned, unless zerolog.DefaultContextLogger is set.
+func (c *Context) RequestLogger() *zerolog.Logger {
+	return zerolog.Ctx(c.Request().Context())
+}
+
+// RequestLogger is the equivalent of the custom context's RequestLogger method for a standard echo context.
+func RequestLogger(c echo.Context) *zerolog.Logger {
+	return zerolog.Ctx(c.Request().Context())
+}
@@ -0,0 +1,141 @@
+package http
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+)
+
+const (
+	// handledErrorKey is the key MarkHandled stores the last handled error under in the echo context.
+	handledErrorKey = "github.com/suborbital/go-kit/web/http.handled"
+
+	// errorHandlerEchoKey is the key FromEcho stores the ErrorHandler of the request under in its echo context.
+	errorHandlerEchoKey = "github.com/suborbital/go-kit/web/http.errorHandler"
+)
+
+// MarkHandled records in the echo context that the error was handled. echo error handlers call it, so they can ignore
+// the error with Handled when a middleware that passed it to c.Error returns it too, the way echo's request logger
+// does.
+func MarkHandled(c echo.Context, err error) {
+	c.Set(handledErrorKey, err)
+}
+
+// Handled returns whether the error is, or wraps, the last error marked handled in the echo context with MarkHandled.
+func Handled(c echo.Context, err error) bool {
+	handled, ok := c.Get(handledErrorKey).(error)
+
+	return ok && errors.Is(err, handled)
+}
+
+// ToEcho turns the Middleware into an echo.MiddlewareFunc.
+//   - the context the Middleware passes on becomes the context of the echo request
+//   - if the Middleware wraps the response writer, the echo handlers get a new echo response that writes through the
+//     wrapper
+//   - errors returned by the echo handlers are returned to the Middleware, and its error is returned to echo
+//   - errors the Middleware passes to Error go to echo's HTTPErrorHandler, through the echo context's Error method
+//   - Route returns the path of the echo route, and ResponseStatus works on the echo response
+func ToEcho(mw Middleware) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			res := c.Response()
+
+			h := mw(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+				c.SetRequest(r.WithContext(ctx))
+
+				if w != http.ResponseWriter(res) {
+					c.SetResponse(echo.NewResponse(w, c.Echo()))
+
+					defer c.SetResponse(res)
+				}
+
+				return next(c)
+			})
+
+			ctx := context.WithValue(c.Request().Context(), routeKey, c.Path())
+			ctx = context.WithValue(ctx, errorHandlerKey, echoErrorHandler(c))
+
+			return h(ctx, res, c.Request().WithContext(ctx))
+		}
+	}
+}
+
+// EchoHandlerFunc turns the Handler into an echo.HandlerFunc, the echo counterpart of HandlerFunc. The Handler gets the
+// echo response as its response writer, and its error is returned to echo. Errors it passes to Error go to echo's
+// HTTPErrorHandler, and Route returns the path of the echo route.
+func EchoHandlerFunc(h Handler) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ctx := context.WithValue(c.Request().Context(), routeKey, c.Path())
+		ctx = context.WithValue(ctx, echoContextKey, c)
+		ctx = context.WithValue(ctx, errorHandlerKey, echoErrorHandler(c))
+
+		c.SetRequest(c.Request().WithContext(ctx))
+
+		return h(ctx, c.Response(), c.Request())
+	}
+}
+
+// echoErrorHandler returns an ErrorHandler that passes errors to the echo context's Error method.
+func echoErrorHandler(c echo.Context) ErrorHandler {
+	return func(_ context.Context, _ http.ResponseWriter, _ *http.Request, err error) {
+		c.Error(err)
+	}
+}
+
+// FromEcho turns the echo.MiddlewareFunc into a Middleware. Every request gets a fresh echo context from an echo
+// instance that's only used for that, so there's no echo router involved.
+//   - the request context set by the echo middleware is passed on as the context of the Handler
+//   - the response writer of the Handler is the echo response, so echo's bookkeeping of the status code and committed
+//     state stays correct. If the response was already committed when the middleware runs, the echo response starts
+//     out committed too
+//   - errors returned by the Handler are returned to the echo middleware, and its error is returned
+//   - errors the echo middleware passes to the echo context's Error method go to the ErrorHandler, see Error. If the
+//     middleware then returns the same error, like echo's request logger does, nil is returned instead, so the error
+//     is not handled twice
+//   - the echo context's Path method returns the pattern of the Mux route, see Route
+func FromEcho(mw echo.MiddlewareFunc) Middleware {
+	e := echo.New()
+	e.HTTPErrorHandler = func(err error, c echo.Context) {
+		MarkHandled(c, err)
+
+		// a middleware made with ToEcho further in puts an ErrorHandler that calls c.Error into the request context, the
+		// error goes to the one the request came in with instead.
+		ctx := c.Request().Context()
+		if eh, ok := c.Get(errorHandlerEchoKey).(ErrorHandler); ok {
+			ctx = context.WithValue(ctx, errorHandlerKey, eh)
+		}
+
+		Error(ctx, c.Response(), c.Request(), err)
+	}
+
+	return func(next Handler) Handler {
+		h := mw(func(c echo.Context) error {
+			return next(c.Request().Context(), c.Response(), c.Request())
+		})
+
+		return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+			c := e.NewContext(r.WithContext(ctx), w)
+			c.SetPath(Route(ctx))
+
+			if eh, ok := ctx.Value(errorHandlerKey).(ErrorHandler); ok {
+				c.Set(errorHandlerEchoKey, eh)
+			}
+
+			if status, committed := ResponseStatus(w); committed {
+				c.Response().Status = status
+				c.Response().Committed = true
+			}
+
+			err := h(c)
+
+			// echo middlewares that handle an error also return it, it shouldn't be handled again.
+			if Handled(c, err) {
+				return nil
+			}
+
+			return err
+		}
+	}
+}
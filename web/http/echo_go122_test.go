@@ -0,0 +1,40 @@
+//go:build go1.22
+
+package http_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+
+	kitError "github.com/suborbital/go-kit/web/error"
+	kitHttp "github.com/suborbital/go-kit/web/http"
+)
+
+func TestFromEcho_route(t *testing.T) {
+	var gotPath string
+
+	mw := func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			gotPath = c.Path()
+			return next(c)
+		}
+	}
+
+	m := kitHttp.NewMux(kitError.HTTPHandler(zerolog.Nop()), kitHttp.FromEcho(mw))
+	m.Handle("GET /orders/{id}", func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	})
+
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/orders/12", nil))
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "GET /orders/{id}", gotPath)
+}
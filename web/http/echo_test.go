@@ -0,0 +1,385 @@
+package http_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	kitError "github.com/suborbital/go-kit/web/error"
+	kitHttp "github.com/suborbital/go-kit/web/http"
+	"github.com/suborbital/go-kit/web/mid"
+)
+
+type ctxKey string
+
+func TestToEcho(t *testing.T) {
+	var (
+		gotErr     error
+		gotStatus  int
+		gotRoute   string
+		gotCtxVal  interface{}
+		handlerRan bool
+	)
+
+	// observe passes a value down in the context, and records what comes back up.
+	observe := func(next kitHttp.Handler) kitHttp.Handler {
+		return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+			gotRoute = kitHttp.Route(ctx)
+
+			err := next(context.WithValue(ctx, ctxKey("from"), "kit middleware"), w, r)
+			gotErr = err
+			gotStatus, _ = kitHttp.ResponseStatus(w)
+
+			return err
+		}
+	}
+
+	// auth short-circuits requests without an authorization header.
+	auth := func(next kitHttp.Handler) kitHttp.Handler {
+		return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+			if r.Header.Get(echo.HeaderAuthorization) == "" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return nil
+			}
+
+			return next(ctx, w, r)
+		}
+	}
+
+	// handled passes errors to the error handler, and doesn't return them.
+	handled := func(next kitHttp.Handler) kitHttp.Handler {
+		return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+			if err := next(ctx, w, r); err != nil {
+				kitHttp.Error(ctx, w, r, err)
+			}
+
+			gotStatus, _ = kitHttp.ResponseStatus(w)
+
+			return nil
+		}
+	}
+
+	tests := []struct {
+		name          string
+		mw            kitHttp.Middleware
+		authorization string
+		handlerErr    error
+		wantStatus    int
+		wantErr       bool
+		wantHandler   bool
+	}{
+		{
+			name:          "passes through",
+			mw:            observe,
+			authorization: "token",
+			wantStatus:    http.StatusOK,
+			wantHandler:   true,
+		},
+		{
+			name:          "error propagates",
+			mw:            observe,
+			authorization: "token",
+			handlerErr:    echo.ErrConflict,
+			wantStatus:    http.StatusConflict,
+			wantErr:       true,
+			wantHandler:   true,
+		},
+		{
+			name:       "short circuit",
+			mw:         auth,
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:        "error handled by the middleware",
+			mw:          handled,
+			handlerErr:  echo.ErrTeapot,
+			wantStatus:  http.StatusTeapot,
+			wantHandler: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotErr, gotStatus, gotRoute, gotCtxVal, handlerRan = nil, 0, "", nil, false
+
+			e := echo.New()
+			e.HTTPErrorHandler = kitError.Handler(zerolog.Nop())
+			e.Use(kitHttp.ToEcho(tt.mw))
+			e.GET("/orders/:id", func(c echo.Context) error {
+				handlerRan = true
+				gotCtxVal = c.Request().Context().Value(ctxKey("from"))
+
+				if tt.handlerErr != nil {
+					return tt.handlerErr
+				}
+
+				return c.String(http.StatusOK, "order")
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/orders/12", nil)
+			req.Header.Set(echo.HeaderAuthorization, tt.authorization)
+			w := httptest.NewRecorder()
+
+			e.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			assert.Equal(t, tt.wantHandler, handlerRan)
+
+			if tt.mw == nil || !tt.wantHandler {
+				return
+			}
+
+			if tt.wantErr {
+				assert.ErrorIs(t, gotErr, tt.handlerErr)
+			}
+
+			if tt.name != "error handled by the middleware" {
+				assert.Equal(t, "/orders/:id", gotRoute)
+				assert.Equal(t, "kit middleware", gotCtxVal)
+			}
+
+			if tt.wantErr || tt.handlerErr == nil {
+				// the echo error handler runs after the middleware returned
+				return
+			}
+
+			assert.Equal(t, tt.wantStatus, gotStatus, "middleware should see the committed status")
+		})
+	}
+}
+
+func TestToEcho_wrappedWriter(t *testing.T) {
+	// counting wraps the response writer the way a compression middleware would.
+	counting := func(next kitHttp.Handler) kitHttp.Handler {
+		return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+			cw := &countingWriter{ResponseWriter: w}
+			err := next(ctx, cw, r)
+			w.Header().Set("X-Bytes", "counted")
+
+			assert.Equal(t, len("hello"), cw.n)
+
+			return err
+		}
+	}
+
+	e := echo.New()
+	e.Use(kitHttp.ToEcho(counting))
+	e.GET("/", func(c echo.Context) error {
+		return c.String(http.StatusOK, "hello")
+	})
+
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, "hello", w.Body.String())
+}
+
+type countingWriter struct {
+	http.ResponseWriter
+	n int
+}
+
+func (cw *countingWriter) Write(b []byte) (int, error) {
+	cw.n += len(b)
+	return cw.ResponseWriter.Write(b)
+}
+
+func TestFromEcho(t *testing.T) {
+	tests := []struct {
+		name       string
+		mw         echo.MiddlewareFunc
+		handler    kitHttp.Handler
+		header     string
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name: "context",
+			mw: func(next echo.HandlerFunc) echo.HandlerFunc {
+				return func(c echo.Context) error {
+					ctx := context.WithValue(c.Request().Context(), ctxKey("from"), "echo middleware")
+					c.SetRequest(c.Request().WithContext(ctx))
+
+					return next(c)
+				}
+			},
+			handler: func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+				_, err := w.Write([]byte(ctx.Value(ctxKey("from")).(string)))
+				return err
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   "echo middleware",
+		},
+		{
+			name: "short circuit",
+			mw: middleware.KeyAuth(func(key string, c echo.Context) (bool, error) {
+				return key == "secret", nil
+			}),
+			handler: func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+				t.Fatal("handler should not run")
+				return nil
+			},
+			header:     "Bearer wrong",
+			wantStatus: http.StatusUnauthorized,
+			wantBody:   `{"message":"Unauthorized","status":401}`,
+		},
+		{
+			name: "error returned to the echo middleware",
+			mw: func(next echo.HandlerFunc) echo.HandlerFunc {
+				return func(c echo.Context) error {
+					err := next(c)
+					if errors.Is(err, echo.ErrNotFound) {
+						return echo.NewHTTPError(http.StatusGone, "order is gone")
+					}
+
+					return err
+				}
+			},
+			handler: func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+				return echo.ErrNotFound
+			},
+			wantStatus: http.StatusGone,
+			wantBody:   `{"message":"order is gone","status":410}`,
+		},
+		{
+			name: "panic recovered by echo middleware",
+			mw:   middleware.Recover(),
+			handler: func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+				panic("boom")
+			},
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   `{"message":"Internal Server Error","status":500}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := kitHttp.HandlerFunc(kitHttp.FromEcho(tt.mw)(tt.handler), kitError.HTTPHandler(zerolog.Nop()))
+
+			req := httptest.NewRequest(http.MethodGet, "/orders/12", nil)
+			if tt.header != "" {
+				req.Header.Set(echo.HeaderAuthorization, tt.header)
+			}
+			w := httptest.NewRecorder()
+
+			h.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			assert.Equal(t, tt.wantBody, strings.TrimSpace(w.Body.String()))
+		})
+	}
+}
+
+func TestFromEcho_handledErrors(t *testing.T) {
+	errLog := bytes.NewBuffer(nil)
+
+	h := kitHttp.HandlerFunc(
+		kitHttp.FromEcho(mid.Logger(zerolog.Nop(), nil))(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+			return echo.ErrForbidden
+		}),
+		kitError.HTTPHandler(zerolog.New(errLog)),
+	)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	lines := strings.Split(strings.TrimSpace(errLog.String()), "\n")
+	require.Len(t, lines, 1, "error should be handled once")
+	assert.Contains(t, lines[0], "request returned an error")
+}
+
+func TestToEcho_FromEcho_roundTrip(t *testing.T) {
+	// handleAndReturn passes the error to the error handler, and returns it too, like echo's request logger.
+	handleAndReturn := func(next kitHttp.Handler) kitHttp.Handler {
+		return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+			err := next(ctx, w, r)
+			if err != nil {
+				kitHttp.Error(ctx, w, r, err)
+			}
+
+			return err
+		}
+	}
+
+	tests := []struct {
+		name    string
+		handler func(errLog *bytes.Buffer) http.Handler
+	}{
+		{
+			name: "kit middleware through echo on kit",
+			handler: func(errLog *bytes.Buffer) http.Handler {
+				mw := kitHttp.FromEcho(kitHttp.ToEcho(handleAndReturn))
+
+				return kitHttp.HandlerFunc(mw(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+					return echo.ErrForbidden
+				}), kitError.HTTPHandler(zerolog.New(errLog)))
+			},
+		},
+		{
+			name: "echo middleware through kit on echo",
+			handler: func(errLog *bytes.Buffer) http.Handler {
+				e := echo.New()
+				e.HTTPErrorHandler = kitError.Handler(zerolog.New(errLog))
+				e.Use(kitHttp.ToEcho(kitHttp.FromEcho(mid.Logger(zerolog.Nop(), nil))))
+				e.GET("/", func(c echo.Context) error {
+					return echo.ErrForbidden
+				})
+
+				return e
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errLog := bytes.NewBuffer(nil)
+
+			w := httptest.NewRecorder()
+			tt.handler(errLog).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			assert.Equal(t, http.StatusForbidden, w.Code)
+			assert.JSONEq(t, `{"message":"Forbidden","status":403}`, w.Body.String())
+
+			lines := strings.Split(strings.TrimSpace(errLog.String()), "\n")
+			require.Len(t, lines, 1, "error should be handled once")
+			assert.Contains(t, lines[0], "request returned an error")
+		})
+	}
+}
+
+func TestFromEcho_panicPropagates(t *testing.T) {
+	h := kitHttp.HandlerFunc(
+		kitHttp.FromEcho(middleware.RequestID())(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+			panic("boom")
+		}),
+		kitError.HTTPHandler(zerolog.Nop()),
+	)
+
+	assert.PanicsWithValue(t, "boom", func() {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	})
+}
+
+func TestToEcho_panic(t *testing.T) {
+	e := echo.New()
+	e.HTTPErrorHandler = kitError.Handler(zerolog.Nop())
+	e.Use(kitHttp.ToEcho(mid.HTTPRecover(zerolog.Nop())))
+	e.GET("/", func(c echo.Context) error {
+		panic("boom")
+	})
+
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, `{"message":"Internal Server Error","status":500}`, strings.TrimSpace(w.Body.String()))
+}
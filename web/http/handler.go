@@ -0,0 +1,54 @@
+package http
+
+import (
+	"context"
+	"net/http"
+)
+
+// ctxKey is the type of the keys this package stores values in contexts with.
+type ctxKey int
+
+const (
+	routeKey ctxKey = iota
+	errorHandlerKey
+	echoContextKey
+)
+
+// ErrorHandler handles an error returned by a Handler, usually by logging it and sending an error response. It's the
+// router-agnostic counterpart of echo.HTTPErrorHandler. The error package has one that works the same way as its echo
+// error handler.
+type ErrorHandler func(ctx context.Context, w http.ResponseWriter, r *http.Request, err error)
+
+// HandlerFunc turns the Handler into an http.HandlerFunc. Errors returned by the Handler are passed to errorHandler.
+// The Handler's response writer has a ResponseRecorder, see Recorder.
+func HandlerFunc(h Handler, errorHandler ErrorHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), errorHandlerKey, errorHandler)
+		_, rw := NewResponseRecorder(w)
+
+		err := h(ctx, rw, r.WithContext(ctx))
+		if err != nil {
+			errorHandler(ctx, rw, r.WithContext(ctx), err)
+		}
+	}
+}
+
+// Error passes the error to the ErrorHandler serving the request, the same way echo.Context's Error method does.
+// Middlewares that need the error response to be sent while they're still running, like the ones that end a span or
+// log the status code, call this and return nil. It does nothing if the request isn't served through HandlerFunc or Mux.
+func Error(ctx context.Context, w http.ResponseWriter, r *http.Request, err error) {
+	eh, ok := ctx.Value(errorHandlerKey).(ErrorHandler)
+	if !ok || err == nil {
+		return
+	}
+
+	eh(ctx, w, r, err)
+}
+
+// Route returns the pattern of the Mux route the request matched, like "GET /orders/{id}". It's the equivalent of
+// echo.Context's Path method, and returns an empty string for requests that didn't match a route.
+func Route(ctx context.Context) string {
+	route, _ := ctx.Value(routeKey).(string)
+
+	return route
+}
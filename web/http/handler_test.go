@@ -0,0 +1,44 @@
+package http_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+
+	kitError "github.com/suborbital/go-kit/web/error"
+	kitHttp "github.com/suborbital/go-kit/web/http"
+)
+
+func TestError(t *testing.T) {
+	var statusInMiddleware int
+
+	// handled stands in for a middleware that needs the response to be sent before it returns.
+	handled := func(next kitHttp.Handler) kitHttp.Handler {
+		return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+			if err := next(ctx, w, r); err != nil {
+				kitHttp.Error(ctx, w, r, err)
+			}
+
+			statusInMiddleware, _ = kitHttp.ResponseStatus(w)
+
+			return nil
+		}
+	}
+
+	h := kitHttp.HandlerFunc(handled(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		return echo.ErrTeapot
+	}), kitError.HTTPHandler(zerolog.Nop()))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, http.StatusTeapot, statusInMiddleware)
+	assert.Equal(t, http.StatusTeapot, w.Code)
+	assert.Equal(t, `{"message":"I'm a teapot","status":418}`, strings.TrimSpace(w.Body.String()))
+}
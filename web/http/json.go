@@ -0,0 +1,233 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// JSONOptions represents configuration options for the handlers created with JSON.
+type JSONOptions struct {
+	status    int
+	validator echo.Validator
+}
+
+// OptionModifier is a type of function that changes values on a JSONOptions struct in place.
+type OptionModifier func(o *JSONOptions)
+
+// WithStatus sets the status code of the response when the function doesn't return an error. If not set, 200 OK is
+// used. With 204 No Content the response is sent without a body.
+func WithStatus(status int) OptionModifier {
+	return func(o *JSONOptions) {
+		o.status = status
+	}
+}
+
+// WithValidator sets the validator the decoded request is validated with. If not set, the Validator of the echo
+// instance is used when the handler is served by echo through EchoHandlerFunc, and the request isn't validated
+// otherwise.
+func WithValidator(v echo.Validator) OptionModifier {
+	return func(o *JSONOptions) {
+		o.validator = v
+	}
+}
+
+// DecodeError is returned by a JSON handler when the request can't be decoded into the request type. Err is the error
+// of the echo binder, or an *echo.BindingError that names the path parameter, query parameter or header that doesn't
+// fit its field. The error package's handlers respond to it the same way as to a binding error passed to its
+// FromBindError function.
+type DecodeError struct {
+	Err error
+}
+
+// Error implements the error interface.
+func (e *DecodeError) Error() string {
+	return "decoding request: " + e.Err.Error()
+}
+
+// Unwrap returns the binder's error.
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// ValidateError is returned by a JSON handler when the decoded request isn't valid. Err is the error of the validator.
+// The error package's handlers respond to it the same way as to a validation error passed to its FromValidateError
+// function.
+type ValidateError struct {
+	Err error
+}
+
+// Error implements the error interface.
+func (e *ValidateError) Error() string {
+	return "validating request: " + e.Err.Error()
+}
+
+// Unwrap returns the validator's error.
+func (e *ValidateError) Unwrap() error {
+	return e.Err
+}
+
+// JSON turns a function that takes a request and returns a response into a Handler, so handlers don't need to repeat
+// the decoding, validation and encoding. The Handler:
+//   - decodes the path parameters, query parameters, headers and body into a new Req, using the param, query, header
+//     and json struct tags, with echo's DefaultBinder. Unlike echo's Bind, query parameters are decoded for every
+//     method, and headers are decoded too. The body is decoded last, so it wins over the others
+//   - validates the Req with the validator, see WithValidator
+//   - calls fn with the request context and the Req
+//   - encodes the Resp as JSON with the status set with WithStatus
+//
+// Decoding and validation errors are returned as a *DecodeError and a *ValidateError, and errors returned by fn are
+// returned as is, so they all reach the ErrorHandler. On a Mux the path parameters are the wildcards of the route
+// pattern. To serve the Handler with echo, use EchoHandlerFunc, which uses echo's path parameters, JSON serializer and
+// Validator.
+func JSON[Req, Resp any](fn func(ctx context.Context, req Req) (Resp, error), options ...OptionModifier) Handler {
+	opts := JSONOptions{
+		status: http.StatusOK,
+	}
+
+	for _, option := range options {
+		option(&opts)
+	}
+
+	e := echo.New()
+	binder := &echo.DefaultBinder{}
+
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		c, ok := ctx.Value(echoContextKey).(echo.Context)
+		if !ok {
+			c = e.NewContext(r, w)
+			c.SetPath(Route(ctx))
+			setPathParams(c, r)
+		}
+
+		var req Req
+		if err := decode(binder, c, &req); err != nil {
+			return &DecodeError{Err: err}
+		}
+
+		validator := opts.validator
+		if validator == nil {
+			validator = c.Echo().Validator
+		}
+
+		if validator != nil {
+			if err := validator.Validate(&req); err != nil {
+				return &ValidateError{Err: err}
+			}
+		}
+
+		resp, err := fn(ctx, req)
+		if err != nil {
+			return err
+		}
+
+		if opts.status == http.StatusNoContent {
+			return c.NoContent(opts.status)
+		}
+
+		return c.JSON(opts.status, resp)
+	}
+}
+
+// decode binds the path parameters, query parameters, headers and body of the request into i, in that order.
+func decode(binder *echo.DefaultBinder, c echo.Context, i interface{}) error {
+	if err := binder.BindPathParams(c, i); err != nil {
+		params := make(map[string][]string, len(c.ParamNames()))
+		for n, name := range c.ParamNames() {
+			params[name] = []string{c.ParamValues()[n]}
+		}
+
+		return paramError(err, i, "param", params, func(name string, values []string) error {
+			pc := c.Echo().NewContext(c.Request(), nil)
+			pc.SetParamNames(name)
+			pc.SetParamValues(values...)
+
+			return binder.BindPathParams(pc, reflect.New(reflect.TypeOf(i).Elem()).Interface())
+		})
+	}
+
+	if err := binder.BindQueryParams(c, i); err != nil {
+		return paramError(err, i, "query", c.QueryParams(), func(name string, values []string) error {
+			r := c.Request().Clone(c.Request().Context())
+			r.URL.RawQuery = url.Values{name: values}.Encode()
+
+			return binder.BindQueryParams(c.Echo().NewContext(r, nil), reflect.New(reflect.TypeOf(i).Elem()).Interface())
+		})
+	}
+
+	if err := binder.BindHeaders(c, i); err != nil {
+		return paramError(err, i, "header", c.Request().Header, func(name string, values []string) error {
+			r := c.Request().Clone(c.Request().Context())
+			r.Header = http.Header{name: values}
+
+			return binder.BindHeaders(c.Echo().NewContext(r, nil), reflect.New(reflect.TypeOf(i).Elem()).Interface())
+		})
+	}
+
+	return binder.BindBody(c, i)
+}
+
+// paramError finds the parameter that made binding the parameters into i fail, by binding them one at a time with
+// bindOne, and returns an *echo.BindingError that names it, with a message for the client like "must be of type int".
+// echo's binder only returns the error of strconv, which names neither. If no parameter of a field with the tag fails
+// on its own, err is returned as is.
+func paramError(err error, i interface{}, tag string, params map[string][]string,
+	bindOne func(name string, values []string) error) error {
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		typ, ok := taggedFieldType(reflect.TypeOf(i).Elem(), tag, name)
+		if !ok || bindOne(name, params[name]) == nil {
+			continue
+		}
+
+		for typ.Kind() == reflect.Pointer || typ.Kind() == reflect.Slice {
+			typ = typ.Elem()
+		}
+
+		return echo.NewBindingError(name, params[name], "must be of type "+typ.String(), err)
+	}
+
+	return err
+}
+
+// taggedFieldType returns the type of the field of the struct type that the parameter is bound to, looking for it the
+// way echo's binder does: by the tag, ignoring case, and in fields of struct types that don't have the tag.
+func taggedFieldType(typ reflect.Type, tag, name string) (reflect.Type, bool) {
+	if typ.Kind() != reflect.Struct {
+		return nil, false
+	}
+
+	for n := 0; n < typ.NumField(); n++ {
+		field := typ.Field(n)
+
+		if tagName := field.Tag.Get(tag); tagName != "" {
+			if strings.EqualFold(tagName, name) {
+				return field.Type, true
+			}
+
+			continue
+		}
+
+		ft := field.Type
+		if ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+
+		if t, ok := taggedFieldType(ft, tag, name); ok {
+			return t, true
+		}
+	}
+
+	return nil, false
+}
@@ -0,0 +1,50 @@
+//go:build go1.22
+
+package http_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+
+	kitError "github.com/suborbital/go-kit/web/error"
+	kitHttp "github.com/suborbital/go-kit/web/http"
+)
+
+func TestJSON_mux(t *testing.T) {
+	for _, tt := range jsonTests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := kitHttp.NewMux(kitError.HTTPHandler(zerolog.Nop()))
+			m.Handle("PUT /orders/{id}", kitHttp.JSON(updateOrderHandler, tt.options...))
+
+			w := httptest.NewRecorder()
+			m.ServeHTTP(w, tt.newRequest())
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			assert.Equal(t, tt.wantBody, strings.TrimSpace(w.Body.String()))
+		})
+	}
+}
+
+func TestJSON_wildcards(t *testing.T) {
+	type file struct {
+		Bucket string `param:"bucket" json:"bucket"`
+		Path   string `param:"path" json:"path"`
+	}
+
+	m := kitHttp.NewMux(kitError.HTTPHandler(zerolog.Nop()))
+	m.Handle("GET /buckets/{bucket}/files/{path...}", kitHttp.JSON(func(_ context.Context, req file) (file, error) {
+		return req, nil
+	}))
+
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/buckets/b1/files/a/b.txt", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `{"bucket":"b1","path":"a/b.txt"}`, strings.TrimSpace(w.Body.String()))
+}
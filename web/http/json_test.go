@@ -0,0 +1,172 @@
+package http_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+
+	kitError "github.com/suborbital/go-kit/web/error"
+	kitHttp "github.com/suborbital/go-kit/web/http"
+)
+
+type updateOrder struct {
+	ID       int    `param:"id"`
+	DryRun   bool   `query:"dry_run"`
+	Tenant   string `header:"X-Tenant"`
+	Quantity int    `json:"quantity"`
+}
+
+type order struct {
+	ID       int    `json:"id"`
+	Tenant   string `json:"tenant"`
+	Quantity int    `json:"quantity"`
+	DryRun   bool   `json:"dryRun"`
+}
+
+// orderValidator is an echo.Validator that requires a positive quantity.
+type orderValidator struct{}
+
+func (orderValidator) Validate(i interface{}) error {
+	req, ok := i.(*updateOrder)
+	if !ok {
+		return errors.New("unexpected request type")
+	}
+
+	ve := kitError.NewValidationError()
+	if req.Quantity <= 0 {
+		ve.Add("quantity", "gt", "must be greater than 0")
+	}
+
+	return ve.ErrorOrNil()
+}
+
+func updateOrderHandler(_ context.Context, req updateOrder) (order, error) {
+	if req.ID == 404 {
+		return order{}, kitError.NotFound(nil, "order not found")
+	}
+
+	return order{ID: req.ID, Tenant: req.Tenant, Quantity: req.Quantity, DryRun: req.DryRun}, nil
+}
+
+// jsonTest is a request to PUT /orders/:id, served by kitHttp.JSON(updateOrderHandler).
+type jsonTest struct {
+	name       string
+	target     string
+	body       string
+	options    []kitHttp.OptionModifier
+	wantStatus int
+	wantBody   string
+}
+
+// newRequest returns the request of the test.
+func (tt jsonTest) newRequest() *http.Request {
+	req := httptest.NewRequest(http.MethodPut, tt.target, strings.NewReader(tt.body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set("X-Tenant", "acme")
+
+	return req
+}
+
+// jsonTests are run with echo by TestJSON, and with kitHttp.Mux by TestJSON_mux.
+var jsonTests = []jsonTest{
+	{
+		name:       "decodes every source",
+		target:     "/orders/12?dry_run=true",
+		body:       `{"quantity":3}`,
+		wantStatus: http.StatusOK,
+		wantBody:   `{"id":12,"tenant":"acme","quantity":3,"dryRun":true}`,
+	},
+	{
+		name:       "custom status",
+		target:     "/orders/12",
+		body:       `{"quantity":3}`,
+		options:    []kitHttp.OptionModifier{kitHttp.WithStatus(http.StatusAccepted)},
+		wantStatus: http.StatusAccepted,
+		wantBody:   `{"id":12,"tenant":"acme","quantity":3,"dryRun":false}`,
+	},
+	{
+		name:       "no content",
+		target:     "/orders/12",
+		body:       `{"quantity":3}`,
+		options:    []kitHttp.OptionModifier{kitHttp.WithStatus(http.StatusNoContent)},
+		wantStatus: http.StatusNoContent,
+	},
+	{
+		name:       "invalid path parameter",
+		target:     "/orders/twelve",
+		body:       `{"quantity":3}`,
+		wantStatus: http.StatusBadRequest,
+		wantBody: `{"errors":[{"field":"id","rule":"type","message":"must be of type int"}],` +
+			`"message":"request validation failed","status":400}`,
+	},
+	{
+		name:       "invalid query parameter",
+		target:     "/orders/12?dry_run=maybe",
+		body:       `{"quantity":3}`,
+		wantStatus: http.StatusBadRequest,
+		wantBody: `{"errors":[{"field":"dry_run","rule":"type","message":"must be of type bool"}],` +
+			`"message":"request validation failed","status":400}`,
+	},
+	{
+		name:       "invalid body",
+		target:     "/orders/12",
+		body:       `{"quantity":"three"}`,
+		wantStatus: http.StatusBadRequest,
+		wantBody: `{"errors":[{"field":"quantity","rule":"type","message":"must be of type int"}],` +
+			`"message":"request validation failed","status":400}`,
+	},
+	{
+		name:       "fails validation",
+		target:     "/orders/12",
+		body:       `{"quantity":0}`,
+		options:    []kitHttp.OptionModifier{kitHttp.WithValidator(orderValidator{})},
+		wantStatus: http.StatusUnprocessableEntity,
+		wantBody: `{"errors":[{"field":"quantity","rule":"gt","message":"must be greater than 0"}],` +
+			`"message":"request validation failed","status":422}`,
+	},
+	{
+		name:       "function error",
+		target:     "/orders/404",
+		body:       `{"quantity":3}`,
+		wantStatus: http.StatusNotFound,
+		wantBody:   `{"message":"order not found","status":404}`,
+	},
+}
+
+func TestJSON(t *testing.T) {
+	for _, tt := range jsonTests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			e.HTTPErrorHandler = kitError.Handler(zerolog.Nop())
+			e.PUT("/orders/:id", kitHttp.EchoHandlerFunc(kitHttp.JSON(updateOrderHandler, tt.options...)))
+
+			w := httptest.NewRecorder()
+			e.ServeHTTP(w, tt.newRequest())
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			assert.Equal(t, tt.wantBody, strings.TrimSpace(w.Body.String()))
+		})
+	}
+}
+
+func TestJSON_echoValidator(t *testing.T) {
+	e := echo.New()
+	e.HTTPErrorHandler = kitError.Handler(zerolog.Nop())
+	e.Validator = orderValidator{}
+	e.PUT("/orders/:id", kitHttp.EchoHandlerFunc(kitHttp.JSON(updateOrderHandler)))
+
+	req := httptest.NewRequest(http.MethodPut, "/orders/12", strings.NewReader(`{"quantity":0}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	w := httptest.NewRecorder()
+
+	e.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+}
@@ -0,0 +1,97 @@
+//go:build go1.22
+
+package http
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Mux is an http.ServeMux that serves Handlers. Errors returned by the Handlers go to a central ErrorHandler, and
+// requests that don't match any route are passed to it as well, as echo.ErrNotFound or echo.ErrMethodNotAllowed, so
+// every error response has the same shape. Routes use the patterns of http.ServeMux, like "GET /orders/{id}", so Mux
+// is only available when building with Go 1.22 or later, and the main module has to declare go 1.22 or later in its
+// go.mod, or run with GODEBUG=httpmuxgo121=0, for http.ServeMux to understand them.
+type Mux struct {
+	mux          *http.ServeMux
+	errorHandler ErrorHandler
+	middlewares  []Middleware
+}
+
+// NewMux returns a Mux with the error handler, and the middlewares that wrap every route, in the order they are
+// executed by requests.
+func NewMux(errorHandler ErrorHandler, mw ...Middleware) *Mux {
+	return &Mux{
+		mux:          http.NewServeMux(),
+		errorHandler: errorHandler,
+		middlewares:  mw,
+	}
+}
+
+// Handle registers the Handler for the pattern. The route's own middlewares run after the ones passed to NewMux. Like
+// http.ServeMux, it panics if the pattern is invalid or conflicts with an already registered one.
+func (m *Mux) Handle(pattern string, h Handler, mw ...Middleware) {
+	h = WrapMiddleware(mw, h)
+	h = WrapMiddleware(m.middlewares, h)
+
+	hf := HandlerFunc(h, m.errorHandler)
+
+	m.mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+		hf(w, r.WithContext(context.WithValue(r.Context(), routeKey, pattern)))
+	})
+}
+
+// ServeHTTP dispatches the request to the Handler whose pattern matches the request. Requests that don't match a route
+// still go through the middlewares passed to NewMux, so they are logged, traced, and so on.
+func (m *Mux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if _, pattern := m.mux.Handler(r); pattern != "" {
+		m.mux.ServeHTTP(w, r)
+		return
+	}
+
+	h := WrapMiddleware(m.middlewares, m.unmatched)
+	HandlerFunc(h, m.errorHandler)(w, r)
+}
+
+// unmatched returns the error for a request that doesn't match any route. http.ServeMux knows whether it's a 404 or a
+// 405, and which methods are allowed, so its response is recorded, and turned into an error.
+func (m *Mux) unmatched(_ context.Context, w http.ResponseWriter, r *http.Request) error {
+	h, _ := m.mux.Handler(r)
+
+	rec := &headerRecorder{header: make(http.Header)}
+	h.ServeHTTP(rec, r)
+
+	if rec.status == http.StatusMethodNotAllowed {
+		w.Header().Set(echo.HeaderAllow, rec.header.Get(echo.HeaderAllow))
+		return echo.ErrMethodNotAllowed
+	}
+
+	return echo.ErrNotFound
+}
+
+// headerRecorder is an http.ResponseWriter that keeps the header and status code, and throws the body away.
+type headerRecorder struct {
+	header http.Header
+	status int
+}
+
+// Header returns the recorded header.
+func (hr *headerRecorder) Header() http.Header {
+	return hr.header
+}
+
+// WriteHeader records the status code.
+func (hr *headerRecorder) WriteHeader(code int) {
+	if hr.status == 0 {
+		hr.status = code
+	}
+}
+
+// Write throws the body away.
+func (hr *headerRecorder) Write(b []byte) (int, error) {
+	hr.WriteHeader(http.StatusOK)
+
+	return len(b), nil
+}
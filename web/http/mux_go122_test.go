@@ -0,0 +1,113 @@
+//go:build go1.22
+
+//go:debug httpmuxgo121=0
+
+package http_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+
+	kitError "github.com/suborbital/go-kit/web/error"
+	kitHttp "github.com/suborbital/go-kit/web/http"
+)
+
+func TestMux(t *testing.T) {
+	var calls []string
+
+	record := func(name string) kitHttp.Middleware {
+		return func(next kitHttp.Handler) kitHttp.Handler {
+			return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+				calls = append(calls, name+" "+kitHttp.Route(ctx))
+				return next(ctx, w, r)
+			}
+		}
+	}
+
+	m := kitHttp.NewMux(kitError.HTTPHandler(zerolog.Nop()), record("global"))
+	m.Handle("GET /orders/{id}", func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		if r.PathValue("id") == "missing" {
+			return kitError.NotFound(nil, "order not found")
+		}
+
+		_, err := w.Write([]byte("order " + r.PathValue("id")))
+
+		return err
+	}, record("route"))
+	m.Handle("POST /orders", func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		return errors.New("db down")
+	})
+
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		wantStatus int
+		wantBody   string
+		wantAllow  string
+		wantCalls  []string
+	}{
+		{
+			name:       "matched route",
+			method:     http.MethodGet,
+			target:     "/orders/12",
+			wantStatus: http.StatusOK,
+			wantBody:   "order 12",
+			wantCalls:  []string{"global GET /orders/{id}", "route GET /orders/{id}"},
+		},
+		{
+			name:       "handler error",
+			method:     http.MethodGet,
+			target:     "/orders/missing",
+			wantStatus: http.StatusNotFound,
+			wantBody:   `{"message":"order not found","status":404}`,
+			wantCalls:  []string{"global GET /orders/{id}", "route GET /orders/{id}"},
+		},
+		{
+			name:       "internal error",
+			method:     http.MethodPost,
+			target:     "/orders",
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   `{"message":"Internal Server Error","status":500}`,
+			wantCalls:  []string{"global POST /orders"},
+		},
+		{
+			name:       "not found",
+			method:     http.MethodGet,
+			target:     "/customers",
+			wantStatus: http.StatusNotFound,
+			wantBody:   `{"message":"Not Found","status":404}`,
+			wantCalls:  []string{"global "},
+		},
+		{
+			name:       "method not allowed",
+			method:     http.MethodDelete,
+			target:     "/orders/12",
+			wantStatus: http.StatusMethodNotAllowed,
+			wantBody:   `{"message":"Method Not Allowed","status":405}`,
+			wantAllow:  "GET, HEAD",
+			wantCalls:  []string{"global "},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls = nil
+
+			w := httptest.NewRecorder()
+			m.ServeHTTP(w, httptest.NewRequest(tt.method, tt.target, nil))
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			assert.Equal(t, tt.wantBody, strings.TrimSpace(w.Body.String()))
+			assert.Equal(t, tt.wantAllow, w.Header().Get(echo.HeaderAllow))
+			assert.Equal(t, tt.wantCalls, calls)
+		})
+	}
+}
@@ -0,0 +1,44 @@
+//go:build go1.22
+
+package http
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// setPathParams sets the wildcards of the Mux route pattern the request matched as the path parameters of the echo
+// context.
+func setPathParams(c echo.Context, r *http.Request) {
+	c.SetParamNames(pathParamNames(c.Path())...)
+
+	values := make([]string, 0, len(c.ParamNames()))
+	for _, name := range c.ParamNames() {
+		values = append(values, r.PathValue(name))
+	}
+
+	c.SetParamValues(values...)
+}
+
+// pathParamNames returns the names of the wildcards in the http.ServeMux pattern, like "id" for "GET /orders/{id}" and
+// "path" for "/files/{path...}".
+func pathParamNames(pattern string) []string {
+	var names []string
+
+	for _, segment := range strings.Split(pattern, "/") {
+		if !strings.HasPrefix(segment, "{") || !strings.HasSuffix(segment, "}") {
+			continue
+		}
+
+		name := strings.TrimSuffix(strings.Trim(segment, "{}"), "...")
+		if name == "$" {
+			continue
+		}
+
+		names = append(names, name)
+	}
+
+	return names
+}
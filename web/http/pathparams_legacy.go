@@ -0,0 +1,13 @@
+//go:build !go1.22
+
+package http
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// setPathParams does nothing before Go 1.22. Mux needs Go 1.22, and the patterns of http.ServeMux have no wildcards
+// before that.
+func setPathParams(echo.Context, *http.Request) {}
@@ -0,0 +1,344 @@
+package http
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ResponseRecorder records the status code, the number of bytes written, when the first byte was sent, and whether the
+// response has been committed, the way *echo.Response does for echo handlers. HandlerFunc and Mux pass every Handler a
+// response writer with a ResponseRecorder, which middlewares get with Recorder.
+type ResponseRecorder struct {
+	w         http.ResponseWriter
+	status    int
+	size      int64
+	committed bool
+	started   time.Time
+	firstByte time.Time
+}
+
+// NewResponseRecorder wraps w with a ResponseRecorder, and returns the recorder, and the response writer to write the
+// response to. The returned writer implements http.Flusher, http.Hijacker, io.ReaderFrom and http.Pusher only if w
+// does, so checking for them with a type assertion works the same way as on w. If w already has a ResponseRecorder,
+// that one and w are returned.
+func NewResponseRecorder(w http.ResponseWriter) (*ResponseRecorder, http.ResponseWriter) {
+	if rr, ok := w.(recorded); ok {
+		return rr.recorder(), w
+	}
+
+	rr := &ResponseRecorder{
+		w:       w,
+		status:  http.StatusOK,
+		started: time.Now(),
+	}
+
+	return rr, rr.wrap()
+}
+
+// Recorder returns the ResponseRecorder of the response writer, following the Unwrap methods of response writers
+// that wrap it. It returns false if there isn't one.
+func Recorder(w http.ResponseWriter) (*ResponseRecorder, bool) {
+	for w != nil {
+		if rr, ok := w.(recorded); ok {
+			return rr.recorder(), true
+		}
+
+		u, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			break
+		}
+
+		w = u.Unwrap()
+	}
+
+	return nil, false
+}
+
+// Header returns the header of the response.
+func (rr *ResponseRecorder) Header() http.Header {
+	return rr.w.Header()
+}
+
+// WriteHeader sends the header with the status code. Calls after the response has been committed are ignored.
+// Informational status codes other than 101 Switching Protocols are sent without committing the response, like
+// net/http does.
+func (rr *ResponseRecorder) WriteHeader(code int) {
+	if rr.committed {
+		return
+	}
+
+	if code >= 100 && code < 200 && code != http.StatusSwitchingProtocols {
+		rr.w.WriteHeader(code)
+		return
+	}
+
+	rr.commit(code)
+	rr.w.WriteHeader(code)
+}
+
+// Write writes the body, committing the response with 200 OK if it hasn't been committed yet.
+func (rr *ResponseRecorder) Write(b []byte) (int, error) {
+	if !rr.committed {
+		rr.WriteHeader(http.StatusOK)
+	}
+
+	n, err := rr.w.Write(b)
+	rr.size += int64(n)
+
+	return n, err
+}
+
+// Unwrap returns the wrapped http.ResponseWriter, so http.ResponseController can reach it.
+func (rr *ResponseRecorder) Unwrap() http.ResponseWriter {
+	return rr.w
+}
+
+// Status returns the status code of the response. It's 200 OK until the response is committed with another one.
+func (rr *ResponseRecorder) Status() int {
+	return rr.status
+}
+
+// Size returns the number of bytes of the body written so far.
+func (rr *ResponseRecorder) Size() int64 {
+	return rr.size
+}
+
+// Committed returns whether the header has been sent, after which the status code can't change.
+func (rr *ResponseRecorder) Committed() bool {
+	return rr.committed
+}
+
+// Started returns when the ResponseRecorder was created, which is when HandlerFunc and Mux started serving the request.
+func (rr *ResponseRecorder) Started() time.Time {
+	return rr.started
+}
+
+// FirstByte returns when the response was committed, or the zero time if it hasn't been yet. Subtract Started from it
+// to get the time to first byte.
+func (rr *ResponseRecorder) FirstByte() time.Time {
+	return rr.firstByte
+}
+
+// commit marks the response committed with the status code.
+func (rr *ResponseRecorder) commit(code int) {
+	rr.status = code
+	rr.committed = true
+	rr.firstByte = time.Now()
+}
+
+// flush commits the response with 200 OK if it hasn't been committed yet, and flushes it.
+func (rr *ResponseRecorder) flush() {
+	if !rr.committed {
+		rr.WriteHeader(http.StatusOK)
+	}
+
+	rr.w.(http.Flusher).Flush()
+}
+
+// hijack hands the connection over to the caller. The response counts as committed afterwards, so nothing else tries
+// to write an error response to it.
+func (rr *ResponseRecorder) hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := rr.w.(http.Hijacker).Hijack()
+	if err == nil {
+		rr.committed = true
+	}
+
+	return conn, rw, err
+}
+
+// readFrom copies the body from r with the wrapped writer's ReadFrom, which can use sendfile.
+func (rr *ResponseRecorder) readFrom(r io.Reader) (int64, error) {
+	if !rr.committed {
+		rr.WriteHeader(http.StatusOK)
+	}
+
+	n, err := rr.w.(io.ReaderFrom).ReadFrom(r)
+	rr.size += n
+
+	return n, err
+}
+
+// push initiates an HTTP/2 server push.
+func (rr *ResponseRecorder) push(target string, opts *http.PushOptions) error {
+	return rr.w.(http.Pusher).Push(target, opts)
+}
+
+// recorded is implemented by the response writers NewResponseRecorder returns.
+type recorded interface {
+	recorder() *ResponseRecorder
+}
+
+// recorder returns the ResponseRecorder itself.
+func (rr *ResponseRecorder) recorder() *ResponseRecorder {
+	return rr
+}
+
+type (
+	flushFunc    func()
+	hijackFunc   func() (net.Conn, *bufio.ReadWriter, error)
+	readFromFunc func(r io.Reader) (int64, error)
+	pushFunc     func(target string, opts *http.PushOptions) error
+)
+
+func (f flushFunc) Flush()                                          { f() }
+func (f hijackFunc) Hijack() (net.Conn, *bufio.ReadWriter, error)   { return f() }
+func (f readFromFunc) ReadFrom(r io.Reader) (int64, error)          { return f(r) }
+func (f pushFunc) Push(target string, opts *http.PushOptions) error { return f(target, opts) }
+
+// wrap returns the ResponseRecorder as a response writer that implements the same optional interfaces as the writer
+// it wraps. Every combination needs its own type, so a type assertion on it doesn't succeed when the wrapped writer
+// doesn't implement the interface.
+func (rr *ResponseRecorder) wrap() http.ResponseWriter {
+	var (
+		f  http.Flusher
+		h  http.Hijacker
+		rf io.ReaderFrom
+		p  http.Pusher
+	)
+
+	var mask int
+
+	if _, ok := rr.w.(http.Flusher); ok {
+		f, mask = flushFunc(rr.flush), mask|1
+	}
+
+	if _, ok := rr.w.(http.Hijacker); ok {
+		h, mask = hijackFunc(rr.hijack), mask|2
+	}
+
+	if _, ok := rr.w.(io.ReaderFrom); ok {
+		rf, mask = readFromFunc(rr.readFrom), mask|4
+	}
+
+	if _, ok := rr.w.(http.Pusher); ok {
+		p, mask = pushFunc(rr.push), mask|8
+	}
+
+	switch mask {
+	case 1:
+		return struct {
+			*ResponseRecorder
+			http.Flusher
+		}{rr, f}
+	case 2:
+		return struct {
+			*ResponseRecorder
+			http.Hijacker
+		}{rr, h}
+	case 3:
+		return struct {
+			*ResponseRecorder
+			http.Flusher
+			http.Hijacker
+		}{rr, f, h}
+	case 4:
+		return struct {
+			*ResponseRecorder
+			io.ReaderFrom
+		}{rr, rf}
+	case 5:
+		return struct {
+			*ResponseRecorder
+			http.Flusher
+			io.ReaderFrom
+		}{rr, f, rf}
+	case 6:
+		return struct {
+			*ResponseRecorder
+			http.Hijacker
+			io.ReaderFrom
+		}{rr, h, rf}
+	case 7:
+		return struct {
+			*ResponseRecorder
+			http.Flusher
+			http.Hijacker
+			io.ReaderFrom
+		}{rr, f, h, rf}
+	case 8:
+		return struct {
+			*ResponseRecorder
+			http.Pusher
+		}{rr, p}
+	case 9:
+		return struct {
+			*ResponseRecorder
+			http.Flusher
+			http.Pusher
+		}{rr, f, p}
+	case 10:
+		return struct {
+			*ResponseRecorder
+			http.Hijacker
+			http.Pusher
+		}{rr, h, p}
+	case 11:
+		return struct {
+			*ResponseRecorder
+			http.Flusher
+			http.Hijacker
+			http.Pusher
+		}{rr, f, h, p}
+	case 12:
+		return struct {
+			*ResponseRecorder
+			io.ReaderFrom
+			http.Pusher
+		}{rr, rf, p}
+	case 13:
+		return struct {
+			*ResponseRecorder
+			http.Flusher
+			io.ReaderFrom
+			http.Pusher
+		}{rr, f, rf, p}
+	case 14:
+		return struct {
+			*ResponseRecorder
+			http.Hijacker
+			io.ReaderFrom
+			http.Pusher
+		}{rr, h, rf, p}
+	case 15:
+		return struct {
+			*ResponseRecorder
+			http.Flusher
+			http.Hijacker
+			io.ReaderFrom
+			http.Pusher
+		}{rr, f, h, rf, p}
+	default:
+		return rr
+	}
+}
+
+// ResponseStatus returns the status code of the response, and whether it has been committed. It works for response
+// writers with a ResponseRecorder, like the ones passed to a Handler by HandlerFunc or Mux, and for *echo.Response. For
+// any other response writer it returns 200 and false.
+func ResponseStatus(w http.ResponseWriter) (int, bool) {
+	if res, ok := w.(*echo.Response); ok {
+		return res.Status, res.Committed
+	}
+
+	if rr, ok := Recorder(w); ok {
+		return rr.Status(), rr.Committed()
+	}
+
+	return http.StatusOK, false
+}
+
+// ResponseRID is the equivalent of RID for handlers that don't have an echo context. It returns the request ID stored
+// in the echo.HeaderXRequestID header of the response, or "unknown-request".
+func ResponseRID(w http.ResponseWriter) string {
+	rid := w.Header().Get(echo.HeaderXRequestID)
+	if rid == "" {
+		return noRequestID
+	}
+
+	return rid
+}
@@ -0,0 +1,192 @@
+package http_test
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	kitError "github.com/suborbital/go-kit/web/error"
+	kitHttp "github.com/suborbital/go-kit/web/http"
+)
+
+// fullWriter is a response writer that implements every optional interface, and records which ones were used.
+type fullWriter struct {
+	*httptest.ResponseRecorder
+	used []string
+}
+
+func (fw *fullWriter) Flush() {
+	fw.used = append(fw.used, "flush")
+	fw.ResponseRecorder.Flush()
+}
+
+func (fw *fullWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	fw.used = append(fw.used, "hijack")
+	return nil, nil, nil
+}
+
+func (fw *fullWriter) ReadFrom(r io.Reader) (int64, error) {
+	fw.used = append(fw.used, "readFrom")
+	return io.Copy(fw.ResponseRecorder, r)
+}
+
+func (fw *fullWriter) Push(string, *http.PushOptions) error {
+	fw.used = append(fw.used, "push")
+	return nil
+}
+
+// plainWriter is a response writer that implements none of the optional interfaces.
+type plainWriter struct {
+	http.ResponseWriter
+}
+
+func TestNewResponseRecorder_interfaces(t *testing.T) {
+	tests := []struct {
+		name         string
+		w            http.ResponseWriter
+		wantFlusher  bool
+		wantHijacker bool
+		wantReader   bool
+		wantPusher   bool
+	}{
+		{
+			name: "none",
+			w:    plainWriter{httptest.NewRecorder()},
+		},
+		{
+			name:        "flusher",
+			w:           httptest.NewRecorder(),
+			wantFlusher: true,
+		},
+		{
+			name:         "all of them",
+			w:            &fullWriter{ResponseRecorder: httptest.NewRecorder()},
+			wantFlusher:  true,
+			wantHijacker: true,
+			wantReader:   true,
+			wantPusher:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, w := kitHttp.NewResponseRecorder(tt.w)
+
+			_, isFlusher := w.(http.Flusher)
+			_, isHijacker := w.(http.Hijacker)
+			_, isReader := w.(io.ReaderFrom)
+			_, isPusher := w.(http.Pusher)
+
+			assert.Equal(t, tt.wantFlusher, isFlusher)
+			assert.Equal(t, tt.wantHijacker, isHijacker)
+			assert.Equal(t, tt.wantReader, isReader)
+			assert.Equal(t, tt.wantPusher, isPusher)
+		})
+	}
+}
+
+func TestResponseRecorder(t *testing.T) {
+	fw := &fullWriter{ResponseRecorder: httptest.NewRecorder()}
+	rr, w := kitHttp.NewResponseRecorder(fw)
+
+	assert.Equal(t, http.StatusOK, rr.Status())
+	assert.False(t, rr.Committed())
+	assert.True(t, rr.FirstByte().IsZero())
+
+	w.WriteHeader(http.StatusCreated)
+	w.WriteHeader(http.StatusTeapot)
+
+	_, err := w.Write([]byte("hello "))
+	require.NoError(t, err)
+
+	_, err = w.(io.ReaderFrom).ReadFrom(strings.NewReader("world"))
+	require.NoError(t, err)
+
+	w.(http.Flusher).Flush()
+	require.NoError(t, w.(http.Pusher).Push("/style.css", nil))
+
+	assert.Equal(t, http.StatusCreated, rr.Status())
+	assert.True(t, rr.Committed())
+	assert.Equal(t, int64(len("hello world")), rr.Size())
+	assert.False(t, rr.FirstByte().Before(rr.Started()))
+	assert.Equal(t, []string{"readFrom", "flush", "push"}, fw.used)
+	assert.Equal(t, "hello world", fw.Body.String())
+
+	again, _ := kitHttp.NewResponseRecorder(w)
+	assert.Same(t, rr, again, "a recorded writer isn't wrapped twice")
+}
+
+func TestResponseRecorder_flushCommits(t *testing.T) {
+	rr, w := kitHttp.NewResponseRecorder(httptest.NewRecorder())
+
+	w.(http.Flusher).Flush()
+
+	assert.True(t, rr.Committed())
+	assert.Equal(t, http.StatusOK, rr.Status())
+}
+
+func TestResponseRecorder_hijackCommits(t *testing.T) {
+	rr, w := kitHttp.NewResponseRecorder(&fullWriter{ResponseRecorder: httptest.NewRecorder()})
+
+	_, _, err := w.(http.Hijacker).Hijack()
+	require.NoError(t, err)
+
+	assert.True(t, rr.Committed())
+}
+
+func TestRecorder(t *testing.T) {
+	var (
+		got      *kitHttp.ResponseRecorder
+		found    bool
+		ttfb     time.Duration
+		bodySize int64
+	)
+
+	// measure is a middleware that reads the recorder through a response writer that wraps it.
+	measure := func(next kitHttp.Handler) kitHttp.Handler {
+		return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+			err := next(ctx, &unwrapper{w}, r)
+
+			got, found = kitHttp.Recorder(&unwrapper{w})
+			if found {
+				ttfb = got.FirstByte().Sub(got.Started())
+				bodySize = got.Size()
+			}
+
+			return err
+		}
+	}
+
+	h := kitHttp.HandlerFunc(measure(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		_, err := w.Write([]byte("hello"))
+		return err
+	}), kitError.HTTPHandler(zerolog.Nop()))
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	require.True(t, found)
+	assert.Equal(t, http.StatusOK, got.Status())
+	assert.Equal(t, int64(len("hello")), bodySize)
+	assert.GreaterOrEqual(t, ttfb, time.Duration(0))
+
+	_, found = kitHttp.Recorder(httptest.NewRecorder())
+	assert.False(t, found)
+}
+
+// unwrapper is a response writer that wraps another one, and can be unwrapped.
+type unwrapper struct {
+	http.ResponseWriter
+}
+
+func (u *unwrapper) Unwrap() http.ResponseWriter {
+	return u.ResponseWriter
+}
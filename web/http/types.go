@@ -6,8 +6,8 @@ import (
 )
 
 // Handler is a function signature which retains most of the important bits of http.HandlerFunc, but enhances it with
-// an incoming context, so we don't need to mangle the one in http.Request, and returns an error, which will be useful
-// once we have an error handling middleware.
+// an incoming context, so we don't need to mangle the one in http.Request, and returns an error, which is handed to the
+// ErrorHandler. Use Mux or HandlerFunc to serve it.
 type Handler func(ctx context.Context, w http.ResponseWriter, r *http.Request) error
 
 // Middleware is a function signature that takes a Handler, and returns a Handler. The idea here is that the passed in
@@ -28,3 +28,34 @@ func WrapMiddleware(mw []Middleware, handler Handler) Handler {
 
 	return handler
 }
+
+// ToStd turns the Middleware into a standard net/http middleware, so it can be used with any router. Errors that reach
+// the outside of the Middleware are passed to errorHandler, and so are the ones the Middleware passes to Error.
+func ToStd(mw Middleware, errorHandler ErrorHandler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		h := mw(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+			next.ServeHTTP(w, r.WithContext(ctx))
+
+			return nil
+		})
+
+		return HandlerFunc(h, errorHandler)
+	}
+}
+
+// FromStd turns a standard net/http middleware into a Middleware. The error of the Handler it wraps is returned as is.
+// If the standard middleware doesn't call the Handler, for example because it already sent a response, nil is
+// returned.
+func FromStd(mw func(http.Handler) http.Handler) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+			var err error
+
+			mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				err = next(r.Context(), w, r)
+			})).ServeHTTP(w, r.WithContext(ctx))
+
+			return err
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package mid
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog"
+
+	"github.com/suborbital/go-kit/observability"
+	"github.com/suborbital/go-kit/web/http"
+)
+
+// ContextLogger stores a child logger of the passed in zerolog.Logger in the request context, so code further down the
+// chain can get a correctly tagged logger with only a context.Context by calling zerolog.Ctx(ctx). Handlers can also use
+// http.RequestLogger(c), or the RequestLogger method on the custom context.
+//
+// The child logger has the following fields added to it:
+//   - requestID - http.RID(c), so the request ID middleware needs to wrap this one
+//   - route - c.Path(), the route as it was registered, with the placeholders in
+//   - method - c.Request().Method
+//   - trace ID, span ID, and trace flags of the span in the request context, so the tracing middleware also needs to
+//     wrap this one. See observability.TraceLogger
+func ContextLogger(l zerolog.Logger) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			ctx := req.Context()
+
+			rl := observability.TraceLogger(ctx, l.With().
+				Str("requestID", http.RID(c)).
+				Str("route", c.Path()).
+				Str("method", req.Method).
+				Logger())
+
+			c.SetRequest(req.WithContext(rl.WithContext(ctx)))
+
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package mid_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/sdk/trace"
+
+	"github.com/suborbital/go-kit/observability"
+	kitHttp "github.com/suborbital/go-kit/web/http"
+	"github.com/suborbital/go-kit/web/mid"
+)
+
+func TestContextLogger(t *testing.T) {
+	const expectedRid = "hello-from-the-requestid"
+
+	tp := trace.NewTracerProvider(trace.WithSampler(trace.AlwaysSample()))
+
+	// spanMW stands in for the otelecho middleware.
+	spanMW := func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			ctx, span := tp.Tracer("test").Start(c.Request().Context(), c.Path())
+			defer span.End()
+
+			c.SetRequest(c.Request().WithContext(ctx))
+
+			return next(c)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		handler echo.HandlerFunc
+	}{
+		{
+			name: "logger from request context",
+			handler: func(c echo.Context) error {
+				zerolog.Ctx(c.Request().Context()).Info().Msg("hello")
+
+				return c.NoContent(http.StatusOK)
+			},
+		},
+		{
+			name: "logger from echo context",
+			handler: func(c echo.Context) error {
+				kitHttp.RequestLogger(c).Info().Msg("hello")
+
+				return c.NoContent(http.StatusOK)
+			},
+		},
+		{
+			name: "logger from custom context",
+			handler: func(c echo.Context) error {
+				cc, ok := c.(*kitHttp.Context)
+				if !ok {
+					return echo.NewHTTPError(http.StatusInternalServerError)
+				}
+
+				cc.RequestLogger().Info().Msg("hello")
+
+				return c.NoContent(http.StatusOK)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := bytes.NewBuffer(nil)
+
+			e := echo.New()
+			e.Use(
+				mid.CustomContext(),
+				middleware.RequestIDWithConfig(middleware.RequestIDConfig{
+					Generator: func() string {
+						return expectedRid
+					},
+				}),
+				spanMW,
+				mid.ContextLogger(zerolog.New(b)),
+			)
+			e.GET("/things/:id", tt.handler)
+
+			req := httptest.NewRequest(http.MethodGet, "/things/12", nil)
+			w := httptest.NewRecorder()
+
+			e.ServeHTTP(w, req)
+
+			require.Equal(t, http.StatusOK, w.Result().StatusCode)
+
+			fields := make(map[string]interface{})
+			require.NoError(t, json.Unmarshal(b.Bytes(), &fields))
+
+			assert.Equal(t, expectedRid, fields["requestID"])
+			assert.Equal(t, "/things/:id", fields["route"])
+			assert.Equal(t, http.MethodGet, fields["method"])
+			assert.Equal(t, "hello", fields["message"])
+			assert.NotEmpty(t, fields[observability.TraceIDFieldName])
+			assert.NotEmpty(t, fields[observability.SpanIDFieldName])
+		})
+	}
+}
+
+func TestRequestLogger_withoutMiddleware(t *testing.T) {
+	e := echo.New()
+	e.GET("/", func(c echo.Context) error {
+		l := kitHttp.RequestLogger(c)
+
+		return c.String(http.StatusOK, l.GetLevel().String())
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	w := httptest.NewRecorder()
+
+	e.ServeHTTP(w, req)
+
+	assert.Equal(t, zerolog.Disabled.String(), w.Body.String())
+}
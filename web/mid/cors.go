@@ -1,8 +1,15 @@
 package mid
 
 import (
+	"context"
+	"net/http"
+	"regexp"
+	"strings"
+
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
+
+	kitHttp "github.com/suborbital/go-kit/web/http"
 )
 
 // CORSOptions represents configuration options for the CORS middleware to further customize the built-in echo CORS
@@ -10,7 +17,9 @@ import (
 type CORSOptions struct {
 	domains           []string
 	additionalHeaders []string
+	methods           []string
 	skipper           func(echo.Context) bool
+	httpSkipper       func(*http.Request) bool
 }
 
 // OptionModifier is a type of function that changes values on a CORSOptions struct in place.
@@ -19,14 +28,94 @@ type OptionModifier func(c *CORSOptions)
 // CORS configures echo's CORS middleware with the following default allowed headers for the specified domain:
 // Origin, Content-Type, Accept, Accept-Encoding, Content-Length, Authorization, Cache-Control.
 //
-// You can use the two modifier functions to add additional domains, or additional headers, for example:
+// Preflight requests are answered with the GET, HEAD, PUT, PATCH, POST and DELETE methods allowed.
+//
+// You can use the modifier functions to add additional domains, or additional headers, or to change the allowed
+// methods, for example:
 //   - WithDomains("example.net", "jquery.com")
 //   - WithHeaders("X-Suborbital-Something", "X-Marks-The-Spot")
+//   - WithMethods(http.MethodGet, http.MethodPost)
 //   - WithSkipper(func(c echo.Context) bool { return c.Path() != "/home" })
 func CORS(domain string, options ...OptionModifier) echo.MiddlewareFunc {
+	corsOptions := newCORSOptions(domain, options...)
+
+	return middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOrigins: corsOptions.domains,
+		AllowHeaders: corsOptions.allowHeaders(),
+		AllowMethods: corsOptions.methods,
+		Skipper:      corsOptions.skipper,
+	})
+}
+
+// HTTPCORS is the kitHttp.Middleware equivalent of CORS. It takes the same options, and sets the same headers as echo's
+// CORS middleware does with them. Use WithHTTPSkipper to skip some requests, the skipper set with WithSkipper is only
+// used by CORS.
+//
+// Preflight requests are answered with 204 No Content without calling the next handler, so the middleware needs to wrap
+// the routes. With a kitHttp.Mux that means passing it to NewMux.
+func HTTPCORS(domain string, options ...OptionModifier) kitHttp.Middleware {
+	corsOptions := newCORSOptions(domain, options...)
+
+	allowMethods := strings.Join(corsOptions.methods, ",")
+	allowHeaders := strings.Join(corsOptions.allowHeaders(), ",")
+
+	allowOriginPatterns := make([]*regexp.Regexp, 0, len(corsOptions.domains))
+	for _, origin := range corsOptions.domains {
+		pattern := regexp.QuoteMeta(origin)
+		pattern = strings.ReplaceAll(pattern, "\\*", ".*")
+		pattern = strings.ReplaceAll(pattern, "\\?", ".")
+		allowOriginPatterns = append(allowOriginPatterns, regexp.MustCompile("^"+pattern+"$"))
+	}
+
+	return func(next kitHttp.Handler) kitHttp.Handler {
+		return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+			if corsOptions.httpSkipper != nil && corsOptions.httpSkipper(r) {
+				return next(ctx, w, r)
+			}
+
+			origin := r.Header.Get(echo.HeaderOrigin)
+			preflight := r.Method == http.MethodOptions
+
+			w.Header().Add(echo.HeaderVary, echo.HeaderOrigin)
+
+			allowOrigin := ""
+			if origin != "" {
+				allowOrigin = matchOrigin(origin, corsOptions.domains, allowOriginPatterns)
+			}
+
+			if allowOrigin == "" {
+				if !preflight {
+					return next(ctx, w, r)
+				}
+
+				w.WriteHeader(http.StatusNoContent)
+
+				return nil
+			}
+
+			w.Header().Set(echo.HeaderAccessControlAllowOrigin, allowOrigin)
+
+			if !preflight {
+				return next(ctx, w, r)
+			}
+
+			w.Header().Add(echo.HeaderVary, echo.HeaderAccessControlRequestMethod)
+			w.Header().Add(echo.HeaderVary, echo.HeaderAccessControlRequestHeaders)
+			w.Header().Set(echo.HeaderAccessControlAllowMethods, allowMethods)
+			w.Header().Set(echo.HeaderAccessControlAllowHeaders, allowHeaders)
+			w.WriteHeader(http.StatusNoContent)
+
+			return nil
+		}
+	}
+}
+
+// newCORSOptions applies the options to the defaults.
+func newCORSOptions(domain string, options ...OptionModifier) CORSOptions {
 	corsOptions := CORSOptions{
 		domains:           []string{domain},
 		additionalHeaders: make([]string, 0),
+		methods:           append([]string(nil), middleware.DefaultCORSConfig.AllowMethods...),
 		skipper:           middleware.DefaultSkipper,
 	}
 
@@ -34,6 +123,11 @@ func CORS(domain string, options ...OptionModifier) echo.MiddlewareFunc {
 		o(&corsOptions)
 	}
 
+	return corsOptions
+}
+
+// allowHeaders returns the default allowed headers, and the additional ones.
+func (c CORSOptions) allowHeaders() []string {
 	corsHeaders := []string{
 		echo.HeaderOrigin,
 		echo.HeaderContentType,
@@ -44,13 +138,30 @@ func CORS(domain string, options ...OptionModifier) echo.MiddlewareFunc {
 		echo.HeaderCacheControl,
 	}
 
-	corsHeaders = append(corsHeaders, corsOptions.domains...)
+	return append(corsHeaders, c.additionalHeaders...)
+}
+
+// matchOrigin returns the value of the Access-Control-Allow-Origin header for the origin, or an empty string if the
+// origin is not allowed. It matches origins the same way echo's CORS middleware does.
+func matchOrigin(origin string, domains []string, patterns []*regexp.Regexp) string {
+	for _, d := range domains {
+		if d == "*" || d == origin {
+			return d
+		}
+	}
 
-	return middleware.CORSWithConfig(middleware.CORSConfig{
-		AllowOrigins: corsOptions.domains,
-		AllowHeaders: corsHeaders,
-		Skipper:      corsOptions.skipper,
-	})
+	// to avoid regex cost by invalid (long) domains (253 is domain name max limit)
+	if len(origin) > 253+3+5 || !strings.Contains(origin, "://") {
+		return ""
+	}
+
+	for _, re := range patterns {
+		if re.MatchString(origin) {
+			return origin
+		}
+	}
+
+	return ""
 }
 
 // WithDomains adds additional domains to the CORS middleware as permitted origins besides the one already passed to the
@@ -69,6 +180,14 @@ func WithHeaders(headers ...string) OptionModifier {
 	}
 }
 
+// WithMethods sets the methods preflight requests are allowed to ask for, instead of GET, HEAD, PUT, PATCH, POST and
+// DELETE.
+func WithMethods(methods ...string) OptionModifier {
+	return func(c *CORSOptions) {
+		c.methods = methods
+	}
+}
+
 // WithSkipper configures a skipper function for the CORS header. If not set, it will use middleware.DefaultSkipper,
 // which enables the middleware to be used on all routes it's attached to.
 func WithSkipper(skipper func(echo.Context) bool) OptionModifier {
@@ -76,3 +195,10 @@ func WithSkipper(skipper func(echo.Context) bool) OptionModifier {
 		c.skipper = skipper
 	}
 }
+
+// WithHTTPSkipper configures a skipper function for HTTPCORS. If not set, HTTPCORS is used on every request.
+func WithHTTPSkipper(skipper func(r *http.Request) bool) OptionModifier {
+	return func(c *CORSOptions) {
+		c.httpSkipper = skipper
+	}
+}
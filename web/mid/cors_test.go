@@ -1,9 +1,13 @@
 package mid
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestWithDomains(t *testing.T) {
@@ -242,3 +246,24 @@ func TestWithDomains(t *testing.T) {
 		})
 	}
 }
+
+func TestCORS_allowHeaders(t *testing.T) {
+	e := echo.New()
+	e.Use(CORS("example.com", WithDomains("example.net"), WithHeaders("X-Marks-The-Spot")))
+	e.GET("/", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodOptions, "/", nil)
+	req.Header.Set(echo.HeaderOrigin, "example.net")
+	req.Header.Set(echo.HeaderAccessControlRequestMethod, http.MethodGet)
+	w := httptest.NewRecorder()
+
+	e.ServeHTTP(w, req)
+
+	allowHeaders := w.Header().Get(echo.HeaderAccessControlAllowHeaders)
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Contains(t, allowHeaders, "X-Marks-The-Spot")
+	assert.NotContains(t, allowHeaders, "example")
+}
@@ -1,11 +1,16 @@
 package mid
 
 import (
+	"context"
+	"net/http"
+	"time"
+
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	"github.com/rs/zerolog"
 
-	"github.com/suborbital/go-kit/web/http"
+	"github.com/suborbital/go-kit/observability"
+	kitHttp "github.com/suborbital/go-kit/web/http"
 )
 
 // Logger middleware configures echo's built in middleware.RequestLoggerWithConfig middleware. The passed in
@@ -39,26 +44,19 @@ import (
 //   - latency - how long the request took. The time is between the logger middleware seeing the request go in, and the
 //     logger middleware seeing the same request return, so it's a total time of every handler and middleware
 //     inside of this middleware
+//
+// Both entries also get the trace ID, span ID and trace flags of the span in the request context through the
+// observability.TraceHook, so the tracing middleware needs to wrap this one for those to be present. The field names
+// can be changed with the observability.TraceIDFieldName, SpanIDFieldName and TraceFlagsFieldName variables.
 func Logger(l zerolog.Logger, skipPaths []string) echo.MiddlewareFunc {
+	l = l.Hook(observability.TraceHook{})
+
 	return middleware.RequestLoggerWithConfig(middleware.RequestLoggerConfig{
 		BeforeNextFunc: func(c echo.Context) {
-			l.Info().
-				Str("path", c.Path()).
-				Str("URI", c.Request().RequestURI).
-				Str("requestID", http.RID(c)).
-				Str("method", c.Request().Method).
-				Msg("request started")
+			logRequestStarted(l, c.Request(), c.Path(), kitHttp.RID(c))
 		},
 		Skipper: func(c echo.Context) bool {
-			path := c.Path()
-
-			for _, sp := range skipPaths {
-				if sp == path {
-					return true
-				}
-			}
-
-			return false
+			return skipPath(skipPaths, c.Path())
 		},
 		HandleError:  true,
 		LogURI:       true,
@@ -67,15 +65,81 @@ func Logger(l zerolog.Logger, skipPaths []string) echo.MiddlewareFunc {
 		LogMethod:    true,
 		LogLatency:   true,
 		LogValuesFunc: func(c echo.Context, v middleware.RequestLoggerValues) error {
-			l.Info().
-				Str("URI", v.URI).
-				Int("status", v.Status).
-				Str("requestID", v.RequestID).
-				Str("method", v.Method).
-				Dur("latency", v.Latency).
-				Msg("request finished")
+			logRequestFinished(l, c.Request().Context(), v.URI, v.Status, v.RequestID, v.Method, v.Latency)
 
 			return nil
 		},
 	})
 }
+
+// HTTPLogger is the kitHttp.Middleware equivalent of Logger, and it logs the same two entries with the same fields. The
+// path field, and the paths to skip, are the patterns of the kitHttp.Mux routes, like "GET /path/{something}".
+//
+// Like Logger, it passes errors to the error handler with kitHttp.Error before logging the finished request, so the
+// status code is the one the client receives. The error is not returned, as it's already been handled.
+func HTTPLogger(l zerolog.Logger, skipPaths []string) kitHttp.Middleware {
+	l = l.Hook(observability.TraceHook{})
+
+	return func(next kitHttp.Handler) kitHttp.Handler {
+		return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+			route := kitHttp.Route(ctx)
+			if skipPath(skipPaths, route) {
+				return next(ctx, w, r)
+			}
+
+			start := time.Now()
+
+			logRequestStarted(l, r.WithContext(ctx), route, kitHttp.ResponseRID(w))
+
+			if err := next(ctx, w, r); err != nil {
+				kitHttp.Error(ctx, w, r, err)
+			}
+
+			rid := r.Header.Get(echo.HeaderXRequestID)
+			if rid == "" {
+				rid = w.Header().Get(echo.HeaderXRequestID)
+			}
+
+			status, _ := kitHttp.ResponseStatus(w)
+
+			logRequestFinished(l, ctx, r.RequestURI, status, rid, r.Method, time.Since(start))
+
+			return nil
+		}
+	}
+}
+
+// skipPath reports whether the path is one of the paths to skip.
+func skipPath(skipPaths []string, path string) bool {
+	for _, sp := range skipPaths {
+		if sp == path {
+			return true
+		}
+	}
+
+	return false
+}
+
+// logRequestStarted logs the "request started" entry of the Logger middlewares.
+func logRequestStarted(l zerolog.Logger, r *http.Request, path, rid string) {
+	l.Info().
+		Ctx(r.Context()).
+		Str("path", path).
+		Str("URI", r.RequestURI).
+		Str("requestID", rid).
+		Str("method", r.Method).
+		Msg("request started")
+}
+
+// logRequestFinished logs the "request finished" entry of the Logger middlewares.
+func logRequestFinished(l zerolog.Logger, ctx context.Context, uri string, status int, rid, method string,
+	latency time.Duration) {
+	l.Info().
+		Ctx(ctx).
+		Str("URI", uri).
+		Int("status", status).
+		Str("requestID", rid).
+		Str("method", method).
+		Dur("latency", latency).
+		Msg("request finished")
+}
@@ -0,0 +1,95 @@
+package mid
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+
+	kitHttp "github.com/suborbital/go-kit/web/http"
+)
+
+// durationHistogramName is the name of the histogram the Metrics middlewares record request durations in.
+const durationHistogramName = "http.server.duration"
+
+// Metrics records the duration of every request in milliseconds in the http.server.duration histogram on the global
+// meter, with the route, method, and status code as attributes. The count of the histogram is the number of requests.
+//
+// Like Logger, it passes errors to the error handler before recording the request, so the status code is the one the
+// client receives, and then returns the error.
+func Metrics(l zerolog.Logger) echo.MiddlewareFunc {
+	rm := newRequestMetrics(l)
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			start := time.Now()
+
+			err := next(c)
+			if err != nil {
+				c.Error(err)
+			}
+
+			rm.record(c.Request().Context(), c.Path(), c.Request().Method, c.Response().Status, time.Since(start))
+
+			return err
+		}
+	}
+}
+
+// HTTPMetrics is the kitHttp.Middleware equivalent of Metrics. The route attribute is the pattern of the kitHttp.Mux
+// route. Errors are passed to the error handler with kitHttp.Error, and not returned.
+func HTTPMetrics(l zerolog.Logger) kitHttp.Middleware {
+	rm := newRequestMetrics(l)
+
+	return func(next kitHttp.Handler) kitHttp.Handler {
+		return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+			start := time.Now()
+
+			if err := next(ctx, w, r); err != nil {
+				kitHttp.Error(ctx, w, r, err)
+			}
+
+			status, _ := kitHttp.ResponseStatus(w)
+
+			rm.record(ctx, kitHttp.Route(ctx), r.Method, status, time.Since(start))
+
+			return nil
+		}
+	}
+}
+
+// requestMetrics is what Metrics and HTTPMetrics have in common.
+type requestMetrics struct {
+	duration metric.Float64Histogram
+}
+
+// newRequestMetrics creates the duration histogram. If that fails, the error is logged, and requests are not recorded.
+func newRequestMetrics(l zerolog.Logger) requestMetrics {
+	duration, err := otel.Meter(instrumentationName).Float64Histogram(durationHistogramName,
+		metric.WithDescription("Duration of http requests."),
+		metric.WithUnit("ms"),
+	)
+	if err != nil {
+		l.Err(err).Str("middleware", "metrics").Msg("creating duration histogram, requests will not be recorded")
+	}
+
+	return requestMetrics{duration: duration}
+}
+
+// record records the duration of the request.
+func (rm requestMetrics) record(ctx context.Context, route, method string, status int, d time.Duration) {
+	if rm.duration == nil {
+		return
+	}
+
+	rm.duration.Record(ctx, float64(d)/float64(time.Millisecond), metric.WithAttributes(
+		attribute.String("http.route", route),
+		attribute.String("http.method", method),
+		attribute.Int("http.status_code", status),
+	))
+}
@@ -0,0 +1,177 @@
+//go:build go1.22
+
+//go:debug httpmuxgo121=0
+
+package mid_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+
+	kitError "github.com/suborbital/go-kit/web/error"
+	kitHttp "github.com/suborbital/go-kit/web/http"
+	"github.com/suborbital/go-kit/web/mid"
+)
+
+// newMuxStacks is newStacks with the kitHttp stack served by a kitHttp.Mux, whose route is GET /orders/{id}.
+func newMuxStacks(echoMW []echo.MiddlewareFunc, kitMW []kitHttp.Middleware, h func(id string) error) stacks {
+	m := kitHttp.NewMux(kitError.HTTPHandler(zerolog.Nop()), kitMW...)
+	m.Handle("GET /orders/{id}", orderHandler(h))
+
+	return stacks{
+		echo: newEchoStack(echoMW, h),
+		kit:  m,
+	}
+}
+
+func TestParity_logger(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus float64
+	}{
+		{
+			name:       "success",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "error is handled before logging",
+			err:        kitError.NotFound(nil, "order not found"),
+			wantStatus: http.StatusNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			echoLog := bytes.NewBuffer(nil)
+			muxLog := bytes.NewBuffer(nil)
+
+			s := newMuxStacks(
+				[]echo.MiddlewareFunc{mid.UUIDRequestID(), mid.Logger(zerolog.New(echoLog), nil)},
+				[]kitHttp.Middleware{mid.HTTPUUIDRequestID(), mid.HTTPLogger(zerolog.New(muxLog), nil)},
+				func(string) error { return tt.err },
+			)
+
+			echoW, muxW := s.serve(func() *http.Request {
+				req := httptest.NewRequest(http.MethodGet, "/orders/12?full=true", nil)
+				req.Header.Set(echo.HeaderXRequestID, "rid")
+
+				return req
+			})
+
+			assert.Equal(t, echoW.Code, muxW.Code)
+			assert.Equal(t, echoW.Body.String(), muxW.Body.String())
+
+			echoEntries := logEntries(t, echoLog)
+			muxEntries := logEntries(t, muxLog)
+			require.Len(t, echoEntries, 2)
+			require.Len(t, muxEntries, 2)
+
+			assert.Equal(t, "/orders/:id", echoEntries[0]["path"])
+			assert.Equal(t, "GET /orders/{id}", muxEntries[0]["path"])
+			assert.Equal(t, tt.wantStatus, muxEntries[1]["status"])
+
+			for i := range echoEntries {
+				for _, e := range []map[string]interface{}{echoEntries[i], muxEntries[i]} {
+					delete(e, "path")
+					delete(e, "latency")
+				}
+
+				assert.Equal(t, echoEntries[i], muxEntries[i])
+			}
+		})
+	}
+}
+
+func TestParity_loggerSkipPaths(t *testing.T) {
+	echoLog := bytes.NewBuffer(nil)
+	muxLog := bytes.NewBuffer(nil)
+
+	s := newMuxStacks(
+		[]echo.MiddlewareFunc{mid.Logger(zerolog.New(echoLog), []string{"/orders/:id"})},
+		[]kitHttp.Middleware{mid.HTTPLogger(zerolog.New(muxLog), []string{"GET /orders/{id}"})},
+		func(string) error { return nil },
+	)
+
+	s.serve(func() *http.Request {
+		return httptest.NewRequest(http.MethodGet, "/orders/12", nil)
+	})
+
+	assert.Empty(t, echoLog.String())
+	assert.Empty(t, muxLog.String())
+}
+
+func TestParity_metrics(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	otel.SetMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+
+	s := newMuxStacks(
+		[]echo.MiddlewareFunc{mid.Metrics(zerolog.Nop())},
+		[]kitHttp.Middleware{mid.HTTPMetrics(zerolog.Nop())},
+		func(id string) error {
+			if id == "missing" {
+				return kitError.NotFound(nil, "order not found")
+			}
+
+			return nil
+		},
+	)
+
+	for _, id := range []string{"12", "missing"} {
+		s.serve(func() *http.Request {
+			return httptest.NewRequest(http.MethodGet, "/orders/"+id, nil)
+		})
+	}
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+
+	var hist metricdata.Histogram[float64]
+	errorCounts := make(map[string]int64)
+
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			switch data := m.Data.(type) {
+			case metricdata.Histogram[float64]:
+				require.Equal(t, "http.server.duration", m.Name)
+				hist = data
+			case metricdata.Sum[int64]:
+				require.Equal(t, "http.server.errors", m.Name)
+
+				for _, dp := range data.DataPoints {
+					route, _ := dp.Attributes.Value("http.route")
+					errorCounts[route.AsString()] = dp.Value
+				}
+			}
+		}
+	}
+
+	assert.Equal(t, map[string]int64{
+		"/orders/:id":      1,
+		"GET /orders/{id}": 1,
+	}, errorCounts, "handled errors should be counted once")
+
+	got := make(map[string]uint64)
+	for _, dp := range hist.DataPoints {
+		route, _ := dp.Attributes.Value("http.route")
+		status, _ := dp.Attributes.Value("http.status_code")
+		got[route.AsString()+" "+status.Emit()] = dp.Count
+	}
+
+	assert.Equal(t, map[string]uint64{
+		"/orders/:id 200":      1,
+		"/orders/:id 404":      1,
+		"GET /orders/{id} 200": 1,
+		"GET /orders/{id} 404": 1,
+	}, got)
+}
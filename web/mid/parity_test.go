@@ -0,0 +1,251 @@
+package mid_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	kitError "github.com/suborbital/go-kit/web/error"
+	kitHttp "github.com/suborbital/go-kit/web/http"
+	"github.com/suborbital/go-kit/web/mid"
+)
+
+// stacks holds the same middleware and handler set up for echo, and for kitHttp.
+type stacks struct {
+	echo *echo.Echo
+	kit  http.Handler
+}
+
+// newStacks returns both stacks with the route GET /orders/:id, whose handler is h. The kitHttp stack serves every
+// request with kitHttp.HandlerFunc, so it has no route, see newMuxStacks for tests that need one.
+func newStacks(echoMW []echo.MiddlewareFunc, kitMW []kitHttp.Middleware, h func(id string) error) stacks {
+	return stacks{
+		echo: newEchoStack(echoMW, h),
+		kit:  kitHttp.HandlerFunc(kitHttp.WrapMiddleware(kitMW, orderHandler(h)), kitError.HTTPHandler(zerolog.Nop())),
+	}
+}
+
+// newEchoStack returns the echo stack of newStacks.
+func newEchoStack(echoMW []echo.MiddlewareFunc, h func(id string) error) *echo.Echo {
+	e := echo.New()
+	e.HTTPErrorHandler = kitError.Handler(zerolog.Nop())
+	e.Use(echoMW...)
+	e.GET("/orders/:id", func(c echo.Context) error {
+		if err := h(c.Param("id")); err != nil {
+			return err
+		}
+
+		return c.String(http.StatusOK, "order "+c.Param("id"))
+	})
+
+	return e
+}
+
+// orderHandler is the kitHttp equivalent of the echo stack's handler. The order ID is the last segment of the path.
+func orderHandler(h func(id string) error) kitHttp.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		id := path.Base(r.URL.Path)
+		if err := h(id); err != nil {
+			return err
+		}
+
+		w.Header().Set(echo.HeaderContentType, echo.MIMETextPlainCharsetUTF8)
+		_, err := w.Write([]byte("order " + id))
+
+		return err
+	}
+}
+
+// serve sends the request built by newReq to both stacks, and returns their responses.
+func (s stacks) serve(newReq func() *http.Request) (*httptest.ResponseRecorder, *httptest.ResponseRecorder) {
+	echoW := httptest.NewRecorder()
+	s.echo.ServeHTTP(echoW, newReq())
+
+	kitW := httptest.NewRecorder()
+	s.kit.ServeHTTP(kitW, newReq())
+
+	return echoW, kitW
+}
+
+func TestParity_requestID(t *testing.T) {
+	s := newStacks(
+		[]echo.MiddlewareFunc{mid.UUIDRequestID()},
+		[]kitHttp.Middleware{mid.HTTPUUIDRequestID()},
+		func(string) error { return nil },
+	)
+
+	echoW, kitW := s.serve(func() *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/orders/12", nil)
+		req.Header.Set(echo.HeaderXRequestID, "incoming-request-id")
+
+		return req
+	})
+
+	assert.Equal(t, "incoming-request-id", echoW.Header().Get(echo.HeaderXRequestID))
+	assert.Equal(t, "incoming-request-id", kitW.Header().Get(echo.HeaderXRequestID))
+
+	echoW, kitW = s.serve(func() *http.Request {
+		return httptest.NewRequest(http.MethodGet, "/orders/12", nil)
+	})
+
+	_, err := uuid.Parse(echoW.Header().Get(echo.HeaderXRequestID))
+	assert.NoError(t, err)
+
+	_, err = uuid.Parse(kitW.Header().Get(echo.HeaderXRequestID))
+	assert.NoError(t, err)
+}
+
+func TestParity_cors(t *testing.T) {
+	options := []mid.OptionModifier{
+		mid.WithDomains("https://*.example.net"),
+		mid.WithHeaders("X-Marks-The-Spot"),
+		mid.WithMethods(http.MethodGet, http.MethodPost),
+	}
+
+	s := newStacks(
+		[]echo.MiddlewareFunc{mid.CORS("https://suborbital.dev", options...)},
+		[]kitHttp.Middleware{mid.HTTPCORS("https://suborbital.dev", options...)},
+		func(string) error { return nil },
+	)
+
+	tests := []struct {
+		name       string
+		method     string
+		origin     string
+		wantStatus int
+		wantOrigin string
+	}{
+		{
+			name:       "allowed origin",
+			method:     http.MethodGet,
+			origin:     "https://suborbital.dev",
+			wantStatus: http.StatusOK,
+			wantOrigin: "https://suborbital.dev",
+		},
+		{
+			name:       "allowed wildcard origin",
+			method:     http.MethodGet,
+			origin:     "https://docs.example.net",
+			wantStatus: http.StatusOK,
+			wantOrigin: "https://docs.example.net",
+		},
+		{
+			name:       "not allowed origin",
+			method:     http.MethodGet,
+			origin:     "https://evil.com",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "no origin",
+			method:     http.MethodGet,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "preflight",
+			method:     http.MethodOptions,
+			origin:     "https://suborbital.dev",
+			wantStatus: http.StatusNoContent,
+			wantOrigin: "https://suborbital.dev",
+		},
+		{
+			name:       "preflight from not allowed origin",
+			method:     http.MethodOptions,
+			origin:     "https://evil.com",
+			wantStatus: http.StatusNoContent,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			echoW, kitW := s.serve(func() *http.Request {
+				req := httptest.NewRequest(tt.method, "/orders/12", nil)
+				if tt.origin != "" {
+					req.Header.Set(echo.HeaderOrigin, tt.origin)
+				}
+
+				return req
+			})
+
+			assert.Equal(t, tt.wantStatus, echoW.Code)
+			assert.Equal(t, tt.wantStatus, kitW.Code)
+			assert.Equal(t, tt.wantOrigin, kitW.Header().Get(echo.HeaderAccessControlAllowOrigin))
+
+			// echo's CORS middleware also answers preflight requests with the Allow header of its router.
+			echoW.Header().Del(echo.HeaderAllow)
+
+			assert.Equal(t, echoW.Header(), kitW.Header())
+			assert.Equal(t, echoW.Body.String(), kitW.Body.String())
+		})
+	}
+
+	_, kitW := s.serve(func() *http.Request {
+		req := httptest.NewRequest(http.MethodOptions, "/orders/12", nil)
+		req.Header.Set(echo.HeaderOrigin, "https://suborbital.dev")
+
+		return req
+	})
+
+	assert.Contains(t, kitW.Header().Get(echo.HeaderAccessControlAllowHeaders), "X-Marks-The-Spot")
+	assert.Equal(t, "GET,POST", kitW.Header().Get(echo.HeaderAccessControlAllowMethods))
+}
+
+func TestParity_recover(t *testing.T) {
+	echoLog := bytes.NewBuffer(nil)
+	kitLog := bytes.NewBuffer(nil)
+
+	s := newStacks(
+		[]echo.MiddlewareFunc{mid.UUIDRequestID(), mid.Recover(zerolog.New(echoLog))},
+		[]kitHttp.Middleware{mid.HTTPUUIDRequestID(), mid.HTTPRecover(zerolog.New(kitLog))},
+		func(string) error { panic("boom") },
+	)
+
+	echoW, kitW := s.serve(func() *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/orders/12", nil)
+		req.Header.Set(echo.HeaderXRequestID, "rid")
+
+		return req
+	})
+
+	assert.Equal(t, http.StatusInternalServerError, kitW.Code)
+	assert.Equal(t, echoW.Code, kitW.Code)
+	assert.Equal(t, echoW.Body.String(), kitW.Body.String())
+
+	echoEntries := logEntries(t, echoLog)
+	kitEntries := logEntries(t, kitLog)
+	require.Len(t, echoEntries, 1)
+	require.Len(t, kitEntries, 1)
+
+	for _, field := range []string{"level", "message", "error", "requestID", "middleware"} {
+		assert.Equal(t, echoEntries[0][field], kitEntries[0][field], field)
+	}
+}
+
+// logEntries parses the JSON log entries in the buffer.
+func logEntries(t *testing.T, b *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+
+	entries := make([]map[string]interface{}, 0)
+
+	for _, line := range strings.Split(strings.TrimSpace(b.String()), "\n") {
+		if line == "" {
+			continue
+		}
+
+		var e map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(line), &e))
+
+		entries = append(entries, e)
+	}
+
+	return entries
+}
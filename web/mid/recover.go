@@ -0,0 +1,185 @@
+package mid
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/suborbital/go-kit/observability"
+	kitHttp "github.com/suborbital/go-kit/web/http"
+)
+
+const (
+	// instrumentationName is the name of the meter the middlewares in this package create their instruments with.
+	instrumentationName = "github.com/suborbital/go-kit/web/mid"
+
+	// panicCounterName is the name of the counter the Recover middleware increments on every panic.
+	panicCounterName = "http.server.panics"
+
+	// maxStackFrames is the number of stack frames logged by the Recover middleware.
+	maxStackFrames = 32
+)
+
+// Recover catches panics in the handlers and middlewares it wraps, and turns them into a 500 Internal Server Error that
+// is handed to the error handler, so the client gets the same response body as for any other error. For every panic it:
+//   - logs the panic value, the stack trace starting at the panic, and the request ID at error level
+//   - records the panic on the span in the request context, and sets the span's status to error
+//   - increments the http.server.panics counter on the global meter, with the route and method as attributes
+//
+// A panic with http.ErrAbortHandler is not recovered, so net/http can abort the response the way it's meant to.
+//
+// This middleware should be as far out as possible, but inside the request ID, tracing, and logger middlewares, so
+// that those are still able to do their jobs on a panicking request.
+func Recover(l zerolog.Logger) echo.MiddlewareFunc {
+	pr := newPanicRecorder(l)
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) (returnErr error) {
+			defer func() {
+				r := recover()
+				if r == nil {
+					return
+				}
+
+				returnErr = pr.recovered(c.Request().Context(), r, c.Path(), c.Request().Method, kitHttp.RID(c))
+			}()
+
+			return next(c)
+		}
+	}
+}
+
+// HTTPRecover is the kitHttp.Middleware equivalent of Recover. It logs, records, and counts panics the same way, and
+// returns the same error, so the client gets the same response. The route attribute of the counter is the pattern of the
+// kitHttp.Mux route.
+func HTTPRecover(l zerolog.Logger) kitHttp.Middleware {
+	pr := newPanicRecorder(l)
+
+	return func(next kitHttp.Handler) kitHttp.Handler {
+		return func(ctx context.Context, w http.ResponseWriter, r *http.Request) (returnErr error) {
+			defer func() {
+				rec := recover()
+				if rec == nil {
+					return
+				}
+
+				returnErr = pr.recovered(ctx, rec, kitHttp.Route(ctx), r.Method, kitHttp.ResponseRID(w))
+			}()
+
+			return next(ctx, w, r)
+		}
+	}
+}
+
+// panicRecorder is what Recover and HTTPRecover have in common.
+type panicRecorder struct {
+	ll     zerolog.Logger
+	panics metric.Int64Counter
+}
+
+// newPanicRecorder sets up the logger and the panic counter.
+func newPanicRecorder(l zerolog.Logger) panicRecorder {
+	ll := l.With().Str("middleware", "recover").Logger().Hook(observability.TraceHook{})
+
+	panics, err := otel.Meter(instrumentationName).Int64Counter(panicCounterName,
+		metric.WithDescription("Number of panics recovered while serving http requests."),
+	)
+	if err != nil {
+		ll.Err(err).Msg("creating panic counter, panics will not be counted")
+	}
+
+	return panicRecorder{ll: ll, panics: panics}
+}
+
+// recovered logs, records and counts the recovered panic value, and returns the error the middleware returns instead.
+// It panics again with http.ErrAbortHandler.
+func (pr panicRecorder) recovered(ctx context.Context, r interface{}, route, method, rid string) error {
+	if r == http.ErrAbortHandler {
+		panic(r)
+	}
+
+	panicErr, ok := r.(error)
+	if !ok {
+		panicErr = fmt.Errorf("%v", r)
+	}
+
+	panicErr = errors.Wrap(panicErr, "recovered from panic")
+
+	pr.ll.Error().
+		Ctx(ctx).
+		Err(panicErr).
+		Str("requestID", rid).
+		Strs("stack", panicStack()).
+		Msg("handler panicked")
+
+	span := trace.SpanFromContext(ctx)
+	span.RecordError(panicErr, trace.WithStackTrace(true))
+	span.SetStatus(codes.Error, "panic")
+
+	if pr.panics != nil {
+		pr.panics.Add(ctx, 1, metric.WithAttributes(
+			attribute.String("http.route", route),
+			attribute.String("http.method", method),
+		))
+	}
+
+	return echo.NewHTTPError(http.StatusInternalServerError).SetInternal(panicError{error: panicErr})
+}
+
+// panicError is the error of a recovered panic. It's already recorded on the span with its stack trace, which it tells
+// the error handler of the web/error package through RecordedOnSpan, so it's not recorded there a second time.
+type panicError struct {
+	error
+}
+
+// Unwrap returns the error the panic value was turned into.
+func (e panicError) Unwrap() error {
+	return e.error
+}
+
+// RecordedOnSpan returns true, the panic is recorded on the span by the middleware.
+func (e panicError) RecordedOnSpan() bool {
+	return true
+}
+
+// panicStack returns the stack of the goroutine that panicked, starting at the function that called panic, as a list
+// of "function file:line" entries. The frames of the runtime and of the recovery itself are left out, and the list is
+// capped at maxStackFrames.
+func panicStack() []string {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(0, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	stack := make([]string, 0, maxStackFrames)
+	afterPanic := false
+
+	for {
+		frame, more := frames.Next()
+
+		if afterPanic && len(stack) < maxStackFrames {
+			stack = append(stack, frame.Function+" "+frame.File+":"+strconv.Itoa(frame.Line))
+		}
+
+		if strings.HasPrefix(frame.Function, "runtime.gopanic") {
+			afterPanic = true
+		}
+
+		if !more {
+			break
+		}
+	}
+
+	return stack
+}
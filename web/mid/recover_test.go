@@ -0,0 +1,184 @@
+package mid_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	kitError "github.com/suborbital/go-kit/web/error"
+	"github.com/suborbital/go-kit/web/mid"
+)
+
+func TestRecover(t *testing.T) {
+	const mockRequestID = "1f550f47-0086-4f92-8d6a-1d5805b2e20e"
+
+	tests := []struct {
+		name       string
+		handler    echo.HandlerFunc
+		wantStatus int
+		wantBody   string
+		wantLog    []string
+		wantPanics int64
+	}{
+		{
+			name: "no panic",
+			handler: func(c echo.Context) error {
+				return c.String(http.StatusOK, "all good")
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   "all good",
+			wantPanics: 0,
+		},
+		{
+			name: "panic with a string",
+			handler: func(c echo.Context) error {
+				panic("boom")
+			},
+			wantStatus: http.StatusInternalServerError,
+			wantBody: `{"message":"Internal Server Error","status":500}
+`,
+			wantLog: []string{
+				"boom",
+				"recover_test.go",
+				mockRequestID,
+				`"message":"handler panicked"`,
+			},
+			wantPanics: 1,
+		},
+		{
+			name: "panic with an error",
+			handler: func(c echo.Context) error {
+				var m map[string]int
+				m["nil map"]++
+
+				return nil
+			},
+			wantStatus: http.StatusInternalServerError,
+			wantBody: `{"message":"Internal Server Error","status":500}
+`,
+			wantLog: []string{
+				"assignment to entry in nil map",
+				"recover_test.go",
+			},
+			wantPanics: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// every case gets its own meter and tracer providers, so the counts don't depend on the other cases.
+			reader := sdkmetric.NewManualReader()
+			otel.SetMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+
+			spans := tracetest.NewSpanRecorder()
+			tp := trace.NewTracerProvider(trace.WithSpanProcessor(spans))
+
+			// spanMW stands in for the otelecho middleware.
+			spanMW := func(next echo.HandlerFunc) echo.HandlerFunc {
+				return func(c echo.Context) error {
+					ctx, span := tp.Tracer("test").Start(c.Request().Context(), c.Path())
+					defer span.End()
+
+					c.SetRequest(c.Request().WithContext(ctx))
+
+					return next(c)
+				}
+			}
+
+			b := bytes.NewBuffer(nil)
+			l := zerolog.New(b)
+
+			e := echo.New()
+			e.HTTPErrorHandler = kitError.Handler(zerolog.Nop())
+			e.Use(
+				mid.UUIDRequestID(),
+				spanMW,
+				mid.Recover(l),
+			)
+			e.GET("/", tt.handler)
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set(echo.HeaderXRequestID, mockRequestID)
+			w := httptest.NewRecorder()
+
+			e.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Result().StatusCode)
+			assert.Equal(t, tt.wantBody, w.Body.String())
+
+			for _, f := range tt.wantLog {
+				assert.Contains(t, b.String(), f)
+			}
+
+			if len(tt.wantLog) == 0 {
+				assert.Empty(t, b.String())
+			}
+
+			ended := spans.Ended()
+			require.Len(t, ended, 1)
+
+			last := ended[0]
+			if tt.wantStatus == http.StatusInternalServerError {
+				assert.Equal(t, codes.Error, last.Status().Code)
+				assert.NotEmpty(t, last.Events(), "panic should be recorded on the span")
+			} else {
+				assert.Equal(t, codes.Unset, last.Status().Code)
+			}
+
+			assert.Equal(t, tt.wantPanics, panicCount(t, reader))
+		})
+	}
+}
+
+func TestRecover_abortHandler(t *testing.T) {
+	e := echo.New()
+	e.Use(mid.Recover(zerolog.Nop()))
+	e.GET("/", func(c echo.Context) error {
+		panic(http.ErrAbortHandler)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	w := httptest.NewRecorder()
+
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		e.ServeHTTP(w, req)
+	})
+}
+
+// panicCount returns the total of the panic counter collected by the reader.
+func panicCount(t *testing.T, reader sdkmetric.Reader) int64 {
+	t.Helper()
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+
+	var total int64
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name != "http.server.panics" {
+				continue
+			}
+
+			sum, ok := m.Data.(metricdata.Sum[int64])
+			require.True(t, ok)
+
+			for _, dp := range sum.DataPoints {
+				total += dp.Value
+			}
+		}
+	}
+
+	return total
+}
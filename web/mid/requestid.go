@@ -1,9 +1,14 @@
 package mid
 
 import (
+	"context"
+	"net/http"
+
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
+
+	kitHttp "github.com/suborbital/go-kit/web/http"
 )
 
 // UUIDRequestID configures echo's built in request id middleware so that the ID generated is an UUIDv4, and the
@@ -20,3 +25,21 @@ func UUIDRequestID() echo.MiddlewareFunc {
 		},
 	})
 }
+
+// HTTPUUIDRequestID is the kitHttp.Middleware equivalent of UUIDRequestID. It uses the request ID in the incoming
+// echo.HeaderXRequestID header, or generates an UUIDv4 if there isn't one, and sets it on the echo.HeaderXRequestID
+// response header, where kitHttp.ResponseRID reads it from.
+func HTTPUUIDRequestID() kitHttp.Middleware {
+	return func(next kitHttp.Handler) kitHttp.Handler {
+		return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+			rid := r.Header.Get(echo.HeaderXRequestID)
+			if rid == "" {
+				rid = uuid.New().String()
+			}
+
+			w.Header().Set(echo.HeaderXRequestID, rid)
+
+			return next(ctx, w, r)
+		}
+	}
+}
@@ -0,0 +1,268 @@
+package server
+
+import (
+	"context"
+	stderrors "errors"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/sdk/trace"
+
+	"github.com/suborbital/go-kit/observability"
+)
+
+const (
+	defaultReadHeaderTimeout = 5 * time.Second
+	defaultReadTimeout       = 30 * time.Second
+	defaultWriteTimeout      = 30 * time.Second
+	defaultIdleTimeout       = 2 * time.Minute
+	defaultShutdownTimeout   = 15 * time.Second
+	defaultFlushTimeout      = 5 * time.Second
+)
+
+// Config holds what Run needs to serve, and to shut down. Only Handler is required, zero durations use the defaults
+// in parentheses.
+type Config struct {
+	// Addr is the TCP address to listen on, like ":8080". It's ignored if Listener is set.
+	Addr string
+
+	// Listener is used instead of listening on Addr, if set. Run closes it, even when it returns an error for the config.
+	Listener net.Listener
+
+	// Handler serves the requests. An *echo.Echo, a kitHttp.Mux, or any other http.Handler. An *echo.Echo is served with
+	// its own Server, so the functions registered with e.Server.RegisterOnShutdown run when it shuts down, and its
+	// Listener is set, so e.ListenerAddr returns the address it listens on.
+	Handler http.Handler
+
+	// ReadHeaderTimeout (5s), ReadTimeout (30s), WriteTimeout (30s) and IdleTimeout (2m) are the timeouts of the
+	// http.Server.
+	ReadHeaderTimeout time.Duration
+	ReadTimeout       time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+
+	// Ready, if set, is set to true once the server listens, and to false as soon as it starts shutting down, so the
+	// readiness check fails while the server drains.
+	Ready *atomic.Bool
+
+	// DrainPeriod is how long the server keeps serving requests after it stopped being ready, so load balancers have
+	// time to notice, and stop sending it new requests. No drain period by default.
+	DrainPeriod time.Duration
+
+	// ShutdownTimeout (15s) is how long the requests that are still running after the drain period get to finish,
+	// before their connections are closed.
+	ShutdownTimeout time.Duration
+
+	// TracerProvider, MeterShutdown and LogWriter are flushed and shut down, in that order, after the server stopped,
+	// so the spans, metrics and logs of the last requests are exported. The log writer goes last, so the logs about
+	// shutting down are exported too. All of them are optional.
+	TracerProvider *trace.TracerProvider
+	MeterShutdown  func(context.Context) error
+	LogWriter      *observability.LogWriter
+
+	// FlushTimeout (5s) is how long the TracerProvider and MeterShutdown get to shut down together, and then how long
+	// the LogWriter gets.
+	FlushTimeout time.Duration
+
+	// Signals that start the shutdown. SIGINT and SIGTERM by default.
+	Signals []os.Signal
+
+	// Logger logs the steps of the lifecycle. Nothing is logged if it's not set.
+	Logger zerolog.Logger
+}
+
+// Run serves HTTP requests with the handler in the config until ctx is canceled, one of the signals is received, or
+// the server fails, and then shuts down gracefully:
+//  1. Ready is set to false, and the server keeps serving for the drain period
+//  2. the server stops accepting connections, and waits for the running requests to finish, up to the shutdown timeout,
+//     after which the remaining connections are closed
+//  3. the tracer provider, the meter provider and the log writer are flushed and shut down, in that order
+//
+// Once shutting down started, the signals are no longer caught, so sending one again stops the process right away.
+//
+// Run returns nil after a graceful shutdown, and the errors of the server and of the shutdown steps otherwise. It
+// returns the error of listening on Addr right away, without shutting down the telemetry, so the caller can still log
+// it.
+func Run(ctx context.Context, config Config) error {
+	config = withDefaults(config)
+	ll := config.Logger.With().Str("module", "server").Logger()
+
+	if config.Handler == nil {
+		if config.Listener != nil {
+			_ = config.Listener.Close()
+		}
+
+		return errors.New("server: the config has no Handler")
+	}
+
+	ln := config.Listener
+	if ln == nil {
+		var err error
+
+		ln, err = net.Listen("tcp", config.Addr)
+		if err != nil {
+			return errors.Wrap(err, "net.Listen")
+		}
+	}
+
+	srv := &http.Server{}
+
+	// echo's own shutdown hooks are registered on its Server, and e.ListenerAddr reads its Listener.
+	if e, ok := config.Handler.(*echo.Echo); ok {
+		if e.Server != nil {
+			srv = e.Server
+		}
+
+		e.Server = srv
+		e.Listener = ln
+	}
+
+	srv.Handler = config.Handler
+	srv.ReadHeaderTimeout = config.ReadHeaderTimeout
+	srv.ReadTimeout = config.ReadTimeout
+	srv.WriteTimeout = config.WriteTimeout
+	srv.IdleTimeout = config.IdleTimeout
+
+	sigCtx, stop := signal.NotifyContext(ctx, config.Signals...)
+	defer stop()
+
+	serveErr := make(chan error, 1)
+
+	go func() {
+		serveErr <- srv.Serve(ln)
+	}()
+
+	if config.Ready != nil {
+		config.Ready.Store(true)
+	}
+
+	ll.Info().Str("addr", ln.Addr().String()).Msg("server started")
+
+	var errs []error
+
+	select {
+	case err := <-serveErr:
+		errs = append(errs, errors.Wrap(err, "srv.Serve"))
+
+		if config.Ready != nil {
+			config.Ready.Store(false)
+		}
+	case <-sigCtx.Done():
+		// a second signal stops the process the default way.
+		stop()
+
+		ll.Info().Msg("shutting down")
+
+		if err := shutdownServer(srv, config, ll); err != nil {
+			errs = append(errs, err)
+		}
+
+		if err := <-serveErr; !errors.Is(err, http.ErrServerClosed) {
+			errs = append(errs, errors.Wrap(err, "srv.Serve"))
+		}
+	}
+
+	errs = append(errs, shutdownTelemetry(config, ll)...)
+
+	ll.Info().Msg("server stopped")
+
+	if config.LogWriter != nil {
+		flushCtx, cancel := context.WithTimeout(context.Background(), config.FlushTimeout)
+		defer cancel()
+
+		if err := config.LogWriter.Shutdown(flushCtx); err != nil {
+			errs = append(errs, errors.Wrap(err, "LogWriter.Shutdown"))
+		}
+	}
+
+	return stderrors.Join(errs...)
+}
+
+// shutdownServer stops being ready, waits for the drain period, and shuts the server down.
+func shutdownServer(srv *http.Server, config Config, ll zerolog.Logger) error {
+	if config.Ready != nil {
+		config.Ready.Store(false)
+	}
+
+	if config.DrainPeriod > 0 {
+		ll.Info().Dur("drainPeriod", config.DrainPeriod).Msg("draining")
+		time.Sleep(config.DrainPeriod)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), config.ShutdownTimeout)
+	defer cancel()
+
+	if err := srv.Shutdown(ctx); err != nil {
+		ll.Warn().Err(err).Msg("requests didn't finish in time, closing their connections")
+
+		return errors.Wrap(stderrors.Join(err, srv.Close()), "srv.Shutdown")
+	}
+
+	return nil
+}
+
+// shutdownTelemetry flushes and shuts down the tracer provider and the meter provider. The log writer is shut down by
+// Run, after the last log line.
+func shutdownTelemetry(config Config, ll zerolog.Logger) []error {
+	ctx, cancel := context.WithTimeout(context.Background(), config.FlushTimeout)
+	defer cancel()
+
+	var errs []error
+
+	if config.TracerProvider != nil {
+		if err := config.TracerProvider.Shutdown(ctx); err != nil {
+			ll.Err(err).Msg("shutting down the tracer provider")
+			errs = append(errs, errors.Wrap(err, "TracerProvider.Shutdown"))
+		}
+	}
+
+	if config.MeterShutdown != nil {
+		if err := config.MeterShutdown(ctx); err != nil {
+			ll.Err(err).Msg("shutting down the meter provider")
+			errs = append(errs, errors.Wrap(err, "MeterShutdown"))
+		}
+	}
+
+	return errs
+}
+
+// withDefaults returns the config with the defaults for the zero values.
+func withDefaults(config Config) Config {
+	if config.ReadHeaderTimeout == 0 {
+		config.ReadHeaderTimeout = defaultReadHeaderTimeout
+	}
+
+	if config.ReadTimeout == 0 {
+		config.ReadTimeout = defaultReadTimeout
+	}
+
+	if config.WriteTimeout == 0 {
+		config.WriteTimeout = defaultWriteTimeout
+	}
+
+	if config.IdleTimeout == 0 {
+		config.IdleTimeout = defaultIdleTimeout
+	}
+
+	if config.ShutdownTimeout == 0 {
+		config.ShutdownTimeout = defaultShutdownTimeout
+	}
+
+	if config.FlushTimeout == 0 {
+		config.FlushTimeout = defaultFlushTimeout
+	}
+
+	if len(config.Signals) == 0 {
+		config.Signals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+
+	return config
+}
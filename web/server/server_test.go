@@ -0,0 +1,239 @@
+package server_test
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/sdk/trace"
+
+	"github.com/suborbital/go-kit/web/server"
+)
+
+// shutdownOrder records the order the telemetry is shut down in.
+type shutdownOrder struct {
+	mu    sync.Mutex
+	order []string
+}
+
+func (s *shutdownOrder) add(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.order = append(s.order, name)
+}
+
+func (s *shutdownOrder) get() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]string(nil), s.order...)
+}
+
+// recordingProcessor is a span processor that records when it's shut down.
+type recordingProcessor struct {
+	order *shutdownOrder
+}
+
+func (recordingProcessor) OnStart(context.Context, trace.ReadWriteSpan) {}
+func (recordingProcessor) OnEnd(trace.ReadOnlySpan)                     {}
+func (recordingProcessor) ForceFlush(context.Context) error             { return nil }
+
+func (p recordingProcessor) Shutdown(context.Context) error {
+	p.order.add("tracer")
+	return nil
+}
+
+func listen(t *testing.T) net.Listener {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	return ln
+}
+
+func TestRun(t *testing.T) {
+	var (
+		ready atomic.Bool
+		order = &shutdownOrder{}
+	)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	var hookCalled atomic.Bool
+
+	e := echo.New()
+	e.Server.RegisterOnShutdown(func() {
+		hookCalled.Store(true)
+	})
+	e.GET("/slow", func(c echo.Context) error {
+		close(started)
+		<-release
+
+		return c.String(http.StatusOK, "done")
+	})
+
+	ln := listen(t)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	runErr := make(chan error, 1)
+
+	go func() {
+		runErr <- server.Run(ctx, server.Config{
+			Listener:       ln,
+			Handler:        e,
+			Ready:          &ready,
+			DrainPeriod:    50 * time.Millisecond,
+			TracerProvider: trace.NewTracerProvider(trace.WithSpanProcessor(recordingProcessor{order: order})),
+			MeterShutdown: func(context.Context) error {
+				order.add("meter")
+				return nil
+			},
+		})
+	}()
+
+	require.Eventually(t, ready.Load, time.Second, time.Millisecond)
+	assert.Equal(t, ln.Addr(), e.ListenerAddr())
+
+	resErr := make(chan error, 1)
+
+	go func() {
+		res, err := http.Get("http://" + ln.Addr().String() + "/slow")
+		if err == nil {
+			err = res.Body.Close()
+			assert.Equal(t, http.StatusOK, res.StatusCode)
+		}
+
+		resErr <- err
+	}()
+
+	<-started
+	cancel()
+
+	require.Eventually(t, func() bool { return !ready.Load() }, time.Second, time.Millisecond)
+	assert.Empty(t, order.get(), "telemetry is shut down after the server")
+
+	close(release)
+
+	require.NoError(t, <-resErr, "the running request finishes")
+	require.NoError(t, <-runErr)
+	assert.Equal(t, []string{"tracer", "meter"}, order.get())
+	// http.Server runs the shutdown hooks in their own goroutines.
+	assert.Eventually(t, hookCalled.Load, time.Second, time.Millisecond, "echo's shutdown hooks run")
+}
+
+func TestRun_signal(t *testing.T) {
+	var ready atomic.Bool
+
+	ln := listen(t)
+	runErr := make(chan error, 1)
+
+	go func() {
+		runErr <- server.Run(context.Background(), server.Config{
+			Listener: ln,
+			Handler:  http.NotFoundHandler(),
+			Ready:    &ready,
+			Signals:  []os.Signal{syscall.SIGUSR1},
+		})
+	}()
+
+	require.Eventually(t, ready.Load, time.Second, time.Millisecond)
+	require.NoError(t, syscall.Kill(syscall.Getpid(), syscall.SIGUSR1))
+
+	select {
+	case err := <-runErr:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("server didn't stop on the signal")
+	}
+
+	assert.False(t, ready.Load())
+}
+
+func TestRun_shutdownTimeout(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+
+	ln := listen(t)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	runErr := make(chan error, 1)
+
+	go func() {
+		runErr <- server.Run(ctx, server.Config{
+			Listener: ln,
+			Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				close(started)
+				<-release
+			}),
+			ShutdownTimeout: 50 * time.Millisecond,
+		})
+	}()
+
+	go func() {
+		res, err := http.Get("http://" + ln.Addr().String())
+		if err == nil {
+			_ = res.Body.Close()
+		}
+	}()
+
+	<-started
+	cancel()
+
+	err := <-runErr
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestRun_errors(t *testing.T) {
+	t.Run("no handler", func(t *testing.T) {
+		assert.EqualError(t, server.Run(context.Background(), server.Config{}), "server: the config has no Handler")
+	})
+
+	t.Run("no handler closes the listener", func(t *testing.T) {
+		ln := listen(t)
+
+		assert.EqualError(t, server.Run(context.Background(), server.Config{Listener: ln}),
+			"server: the config has no Handler")
+
+		_, err := ln.Accept()
+		assert.ErrorIs(t, err, net.ErrClosed)
+	})
+
+	t.Run("address in use", func(t *testing.T) {
+		ln := listen(t)
+		defer ln.Close()
+
+		err := server.Run(context.Background(), server.Config{Addr: ln.Addr().String(), Handler: http.NotFoundHandler()})
+		assert.ErrorContains(t, err, "net.Listen")
+	})
+
+	t.Run("serve fails", func(t *testing.T) {
+		ln := listen(t)
+		require.NoError(t, ln.Close())
+
+		meterShutdown := false
+
+		err := server.Run(context.Background(), server.Config{
+			Listener: ln,
+			Handler:  http.NotFoundHandler(),
+			MeterShutdown: func(context.Context) error {
+				meterShutdown = true
+				return nil
+			},
+		})
+		assert.ErrorContains(t, err, "srv.Serve")
+		assert.True(t, meterShutdown, "telemetry is flushed when the server fails")
+	})
+}
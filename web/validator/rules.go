@@ -0,0 +1,160 @@
+package validator
+
+import (
+	"fmt"
+	"net/mail"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+// uuidPattern matches a UUID in its canonical, hyphenated form, in any version.
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// required fails for nil pointers, empty slices and maps, and zero values.
+func required(value reflect.Value, _ string) error {
+	if isEmpty(value) {
+		return errors.New("is required")
+	}
+
+	return nil
+}
+
+// minimum is the min rule.
+func minimum(value reflect.Value, param string) error {
+	return compare(value, param, func(n, limit float64) bool { return n >= limit }, "at least")
+}
+
+// maximum is the max rule.
+func maximum(value reflect.Value, param string) error {
+	return compare(value, param, func(n, limit float64) bool { return n <= limit }, "at most")
+}
+
+// length is the len rule.
+func length(value reflect.Value, param string) error {
+	return compare(value, param, func(n, limit float64) bool { return n == limit }, "exactly")
+}
+
+// compare compares the length of a string, slice, array or map, or the value of a number, with the limit in param,
+// and describes the limit if ok returns false.
+func compare(value reflect.Value, param string, ok func(n, limit float64) bool, describe string) error {
+	limit, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return errors.Wrapf(ErrInvalidTag, "parameter %q is not a number", param)
+	}
+
+	var n float64
+	var unit string
+
+	switch value.Kind() {
+	case reflect.String:
+		n, unit = float64(utf8.RuneCountInString(value.String())), "characters long"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		n, unit = float64(value.Len()), "items"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n = float64(value.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n = float64(value.Uint())
+	case reflect.Float32, reflect.Float64:
+		n = value.Float()
+	default:
+		return errors.Wrapf(ErrInvalidTag, "can't compare a %s", value.Kind())
+	}
+
+	if ok(n, limit) {
+		return nil
+	}
+
+	switch unit {
+	case "items":
+		return errors.Errorf("must have %s %s items", describe, param)
+	case "":
+		return errors.Errorf("must be %s %s", describe, param)
+	default:
+		return errors.Errorf("must be %s %s %s", describe, param, unit)
+	}
+}
+
+// oneOf is the oneof rule. It compares the value formatted with fmt.Sprint with the space separated values in param.
+func oneOf(value reflect.Value, param string) error {
+	s := fmt.Sprint(value.Interface())
+
+	for _, allowed := range strings.Fields(param) {
+		if s == allowed {
+			return nil
+		}
+	}
+
+	return errors.Errorf("must be one of: %s", strings.Join(strings.Fields(param), ", "))
+}
+
+// email is the email rule.
+func email(value reflect.Value, _ string) error {
+	s, err := stringValue(value)
+	if err != nil {
+		return err
+	}
+
+	addr, err := mail.ParseAddress(s)
+	if err != nil || addr.Address != s {
+		return errors.New("must be a valid email address")
+	}
+
+	return nil
+}
+
+// uuid is the uuid rule.
+func uuid(value reflect.Value, _ string) error {
+	s, err := stringValue(value)
+	if err != nil {
+		return err
+	}
+
+	if !uuidPattern.MatchString(s) {
+		return errors.New("must be a valid UUID")
+	}
+
+	return nil
+}
+
+// newRegexRule returns the regex rule, which compiles every pattern once.
+func newRegexRule() Rule {
+	var patterns sync.Map
+
+	return func(value reflect.Value, param string) error {
+		s, err := stringValue(value)
+		if err != nil {
+			return err
+		}
+
+		re, ok := patterns.Load(param)
+		if !ok {
+			compiled, err := regexp.Compile(param)
+			if err != nil {
+				return errors.Wrapf(ErrInvalidTag, "pattern %q: %s", param, err.Error())
+			}
+
+			re, _ = patterns.LoadOrStore(param, compiled)
+		}
+
+		if !re.(*regexp.Regexp).MatchString(s) {
+			return errors.Errorf("must match %s", param)
+		}
+
+		return nil
+	}
+}
+
+// stringValue returns the value of a string, and an error wrapping ErrInvalidTag for anything else.
+func stringValue(value reflect.Value) (string, error) {
+	if value.Kind() != reflect.String {
+		return "", errors.Wrapf(ErrInvalidTag, "rule needs a string, not a %s", value.Kind())
+	}
+
+	return value.String(), nil
+}
@@ -0,0 +1,372 @@
+package validator
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+
+	kitError "github.com/suborbital/go-kit/web/error"
+)
+
+const (
+	// tagName is the struct tag the rules of a field are read from.
+	tagName = "validate"
+
+	// ruleOmitEmpty skips the rest of the rules if the value is empty.
+	ruleOmitEmpty = "omitempty"
+
+	// ruleDive applies the rules after it to every element of a slice, array or map instead of the value itself.
+	ruleDive = "dive"
+)
+
+// ErrInvalidTag is returned by rules whose parameter doesn't make sense, for example a min rule on a bool. Validate
+// turns it into a 500 Internal Server Error, as it's not the client's fault.
+var ErrInvalidTag = errors.New("invalid validate tag")
+
+// Rule checks a value against a rule. The value is never a pointer, a nil pointer is treated as an empty value. Param is
+// the part of the rule after the =, for example "3" for min=3, and it's empty if there is none. Rule returns nil if the
+// value is valid, or an error whose message tells the client what's wrong, like "must be at least 3". If the rule can't
+// be applied to the value, it returns an error wrapping ErrInvalidTag.
+type Rule func(value reflect.Value, param string) error
+
+// Options represents configuration options for the Validator.
+type Options struct {
+	rules map[string]Rule
+}
+
+// OptionModifier is a type of function that changes values on an Options struct in place.
+type OptionModifier func(o *Options)
+
+// WithRule adds a custom rule, which is used in the validate tag by its name. A rule with the same name as a built-in
+// one replaces it. Like regexp.MustCompile, it panics if the name is empty, contains commas or equal signs, which would
+// never match a tag, or is omitempty or dive, which can't be replaced.
+func WithRule(name string, rule Rule) OptionModifier {
+	switch {
+	case name == "" || strings.ContainsAny(name, ",="):
+		panic(errors.Errorf("validator: rule name %q can't be empty, or contain commas or equal signs", name))
+	case name == ruleOmitEmpty || name == ruleDive:
+		panic(errors.Errorf("validator: rule %s can't be replaced", name))
+	}
+
+	return func(o *Options) {
+		o.rules[name] = rule
+	}
+}
+
+// Validator validates structs with the rules in their validate struct tags, and implements echo.Validator, so it can
+// be set as echo's Validator:
+//
+//	type CreateOrder struct {
+//		Email string   `json:"email" validate:"required,email"`
+//		Items []Item   `json:"items" validate:"required,max=10"`
+//		Tags  []string `json:"tags" validate:"dive,min=2"`
+//	}
+//
+// Rules are separated by commas, and their parameter follows an =. Nested structs, pointers to structs, and slices,
+// arrays and maps of them are validated too. See New for the built-in rules.
+type Validator struct {
+	rules map[string]Rule
+	cache sync.Map
+}
+
+// New returns a Validator with the built-in rules, and the custom rules added with WithRule. The built-in rules are:
+//   - required: the value isn't the zero value, and a slice or map isn't empty
+//   - omitempty: the rest of the rules are skipped if the value is empty
+//   - min=n, max=n, len=n: the length of a string in characters, the number of elements of a slice, array or map, or
+//     the value of a number
+//   - oneof=a b c: the value is one of the space separated values
+//   - email: the value is an email address, without a name
+//   - uuid: the value is a UUID in its canonical, hyphenated form
+//   - regex=pattern: the value matches the regular expression. It takes the rest of the tag, including commas, so it
+//     has to be the last rule
+//   - dive: the rules after it apply to every element of a slice, array or map, rather than to the value itself
+func New(options ...OptionModifier) *Validator {
+	opts := Options{
+		rules: map[string]Rule{
+			"required": required,
+			"min":      minimum,
+			"max":      maximum,
+			"len":      length,
+			"oneof":    oneOf,
+			"email":    email,
+			"uuid":     uuid,
+			"regex":    newRegexRule(),
+		},
+	}
+
+	for _, option := range options {
+		option(&opts)
+	}
+
+	return &Validator{
+		rules: opts.rules,
+	}
+}
+
+// Validate validates i, which is a struct or a pointer to one, and returns a *kitError.ValidationError with every field
+// that broke a rule, or nil if there are none. If a tag can't be parsed or applied, an *echo.HTTPError with a 500
+// Internal Server Error status is returned instead, wrapping the error, so the client isn't blamed for it.
+func (v *Validator) Validate(i interface{}) error {
+	ve := kitError.NewValidationError()
+
+	if err := v.validateStruct(ve, "", reflect.ValueOf(i)); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError).SetInternal(err)
+	}
+
+	return ve.ErrorOrNil()
+}
+
+// validateStruct validates the fields of a struct, or a pointer to one. Other values are ignored.
+func (v *Validator) validateStruct(ve *kitError.ValidationError, path string, rv reflect.Value) error {
+	rv = indirect(rv)
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+
+	fields, err := v.fields(rv.Type())
+	if err != nil {
+		return err
+	}
+
+	for _, f := range fields {
+		if err := v.validateValue(ve, joinPath(path, f.name), rv.Field(f.index), f.rules); err != nil {
+			return errors.Wrapf(err, "field %s.%s", rv.Type().String(), rv.Type().Field(f.index).Name)
+		}
+	}
+
+	return nil
+}
+
+// validateValue applies the rules to the value, and then validates the structs in it.
+func (v *Validator) validateValue(ve *kitError.ValidationError, path string, rv reflect.Value, rules []tagRule) error {
+	for i, r := range rules {
+		switch r.name {
+		case ruleOmitEmpty:
+			if isEmpty(rv) {
+				return nil
+			}
+
+			continue
+		case ruleDive:
+			return v.dive(ve, path, rv, rules[i+1:])
+		}
+
+		rule, ok := v.rules[r.name]
+		if !ok {
+			return errors.Wrapf(ErrInvalidTag, "unknown rule %q", r.name)
+		}
+
+		value := indirect(rv)
+		if !value.IsValid() && r.name != "required" {
+			// rules other than required don't apply to nil pointers, they are just missing values.
+			continue
+		}
+
+		if err := rule(value, r.param); err != nil {
+			if errors.Is(err, ErrInvalidTag) {
+				return errors.Wrapf(err, "rule %s", r.name)
+			}
+
+			ve.Add(path, r.name, err.Error())
+
+			// the rest of the rules would only add noise about the same value.
+			return nil
+		}
+	}
+
+	return v.validateNested(ve, path, rv)
+}
+
+// dive applies the rules to every element of a slice, array or map.
+func (v *Validator) dive(ve *kitError.ValidationError, path string, rv reflect.Value, rules []tagRule) error {
+	rv = indirect(rv)
+
+	switch rv.Kind() {
+	case reflect.Invalid:
+		return nil
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			if err := v.validateValue(ve, path+"["+strconv.Itoa(i)+"]", rv.Index(i), rules); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		iter := rv.MapRange()
+		for iter.Next() {
+			if err := v.validateValue(ve, path+"["+fmt.Sprint(iter.Key().Interface())+"]", iter.Value(), rules); err != nil {
+				return err
+			}
+		}
+	default:
+		return errors.Wrapf(ErrInvalidTag, "dive on a %s", rv.Kind())
+	}
+
+	return nil
+}
+
+// validateNested validates the structs in the value: the value itself, or the elements of a slice, array or map.
+func (v *Validator) validateNested(ve *kitError.ValidationError, path string, rv reflect.Value) error {
+	rv = indirect(rv)
+
+	switch rv.Kind() {
+	case reflect.Struct:
+		return v.validateStruct(ve, path, rv)
+	case reflect.Slice, reflect.Array:
+		if !hasStructs(rv.Type().Elem()) {
+			return nil
+		}
+
+		for i := 0; i < rv.Len(); i++ {
+			if err := v.validateStruct(ve, path+"["+strconv.Itoa(i)+"]", rv.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		if !hasStructs(rv.Type().Elem()) {
+			return nil
+		}
+
+		iter := rv.MapRange()
+		for iter.Next() {
+			if err := v.validateStruct(ve, path+"["+fmt.Sprint(iter.Key().Interface())+"]", iter.Value()); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// structField is a field of a struct, with the name it has in the request and its parsed rules.
+type structField struct {
+	index int
+	name  string
+	rules []tagRule
+}
+
+// tagRule is one rule of a validate tag.
+type tagRule struct {
+	name  string
+	param string
+}
+
+// fields returns the exported fields of the struct type, parsing their tags the first time the type is seen.
+func (v *Validator) fields(t reflect.Type) ([]structField, error) {
+	if cached, ok := v.cache.Load(t); ok {
+		return cached.([]structField), nil
+	}
+
+	fields := make([]structField, 0, t.NumField())
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+
+		rules, err := parseTag(sf.Tag.Get(tagName))
+		if err != nil {
+			return nil, errors.Wrapf(err, "field %s.%s", t.String(), sf.Name)
+		}
+
+		fields = append(fields, structField{
+			index: i,
+			name:  fieldName(sf),
+			rules: rules,
+		})
+	}
+
+	v.cache.Store(t, fields)
+
+	return fields, nil
+}
+
+// parseTag splits a validate tag into its rules.
+func parseTag(tag string) ([]tagRule, error) {
+	var rules []tagRule
+
+	for tag != "" {
+		if pattern, ok := strings.CutPrefix(tag, "regex="); ok {
+			// the pattern can contain commas, so it takes the rest of the tag.
+			rules = append(rules, tagRule{name: "regex", param: pattern})
+			break
+		}
+
+		var part string
+		part, tag, _ = strings.Cut(tag, ",")
+
+		name, param, _ := strings.Cut(part, "=")
+		if name == "" {
+			return nil, errors.Wrap(ErrInvalidTag, "empty rule")
+		}
+
+		rules = append(rules, tagRule{name: name, param: param})
+	}
+
+	return rules, nil
+}
+
+// fieldName returns the name the client knows the field by: the name in its json, query, param, header or form tag,
+// or the name of the field if it has none of them.
+func fieldName(sf reflect.StructField) string {
+	for _, tag := range []string{"json", "query", "param", "header", "form"} {
+		name, _, _ := strings.Cut(sf.Tag.Get(tag), ",")
+		if name != "" && name != "-" {
+			return name
+		}
+	}
+
+	return sf.Name
+}
+
+// joinPath adds the name of a field to the path of its parent.
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+
+	return path + "." + name
+}
+
+// indirect follows pointers and interfaces to the value they point to. It returns the zero reflect.Value for nil.
+func indirect(rv reflect.Value) reflect.Value {
+	for rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return reflect.Value{}
+		}
+
+		rv = rv.Elem()
+	}
+
+	return rv
+}
+
+// isEmpty reports whether the value is a nil pointer, an empty slice or map, or the zero value.
+func isEmpty(rv reflect.Value) bool {
+	rv = indirect(rv)
+	if !rv.IsValid() {
+		return true
+	}
+
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Map:
+		return rv.Len() == 0
+	default:
+		return rv.IsZero()
+	}
+}
+
+// hasStructs reports whether values of the type can contain a struct to validate.
+func hasStructs(t reflect.Type) bool {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	return t.Kind() == reflect.Struct || t.Kind() == reflect.Interface
+}
@@ -0,0 +1,256 @@
+package validator_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	kitError "github.com/suborbital/go-kit/web/error"
+	"github.com/suborbital/go-kit/web/validator"
+)
+
+type item struct {
+	SKU      string `json:"sku" validate:"required,sku"`
+	Quantity int    `json:"quantity" validate:"min=1,max=100"`
+}
+
+type address struct {
+	Country string `json:"country" validate:"required,len=2"`
+}
+
+type order struct {
+	ID       string            `param:"id" validate:"uuid"`
+	Email    string            `json:"email" validate:"required,email"`
+	Name     string            `json:"name" validate:"omitempty,min=2,max=5"`
+	Status   string            `json:"status" validate:"oneof=open closed"`
+	Code     string            `json:"code" validate:"omitempty,regex=^[A-Z]{2,3}$"`
+	Items    []item            `json:"items" validate:"required,max=2"`
+	Tags     []string          `json:"tags" validate:"dive,min=2"`
+	Address  *address          `json:"address"`
+	Labels   map[string]string `json:"labels" validate:"omitempty,dive,required"`
+	internal string            `validate:"required"`
+}
+
+// sku is a custom rule.
+func sku(value reflect.Value, _ string) error {
+	if !strings.HasPrefix(value.String(), "SKU-") {
+		return errors.New("must start with SKU-")
+	}
+
+	return nil
+}
+
+func validOrder() order {
+	return order{
+		ID:      "1b4e28ba-2fa1-11d2-883f-0016d3cca427",
+		Email:   "jane@example.com",
+		Status:  "open",
+		Items:   []item{{SKU: "SKU-1", Quantity: 1}},
+		Tags:    []string{"ab"},
+		Address: &address{Country: "CA"},
+	}
+}
+
+func TestValidator_Validate(t *testing.T) {
+	tests := []struct {
+		name       string
+		modify     func(o *order)
+		wantFields []kitError.FieldError
+	}{
+		{
+			name:   "valid",
+			modify: func(o *order) {},
+		},
+		{
+			name: "required and email",
+			modify: func(o *order) {
+				o.Email = ""
+				o.Items = nil
+			},
+			wantFields: []kitError.FieldError{
+				{Field: "email", Rule: "required", Message: "is required"},
+				{Field: "items", Rule: "required", Message: "is required"},
+			},
+		},
+		{
+			name: "formats",
+			modify: func(o *order) {
+				o.ID = "not-a-uuid"
+				o.Email = "Jane <jane@example.com>"
+				o.Status = "lost"
+				o.Code = "a,b"
+			},
+			wantFields: []kitError.FieldError{
+				{Field: "id", Rule: "uuid", Message: "must be a valid UUID"},
+				{Field: "email", Rule: "email", Message: "must be a valid email address"},
+				{Field: "status", Rule: "oneof", Message: "must be one of: open, closed"},
+				{Field: "code", Rule: "regex", Message: "must match ^[A-Z]{2,3}$"},
+			},
+		},
+		{
+			name: "lengths and limits",
+			modify: func(o *order) {
+				o.Name = "Bartholomew"
+				o.Items = []item{{SKU: "SKU-1", Quantity: 1}, {SKU: "SKU-2", Quantity: 1}, {SKU: "SKU-3", Quantity: 1}}
+			},
+			wantFields: []kitError.FieldError{
+				{Field: "name", Rule: "max", Message: "must be at most 5 characters long"},
+				{Field: "items", Rule: "max", Message: "must have at most 2 items"},
+			},
+		},
+		{
+			name: "nested structs and slices",
+			modify: func(o *order) {
+				o.Items = []item{{SKU: "SKU-1", Quantity: 1}, {SKU: "ABC", Quantity: 0}}
+				o.Tags = []string{"ab", "c"}
+				o.Address = &address{Country: "CAN"}
+				o.Labels = map[string]string{"team": ""}
+			},
+			wantFields: []kitError.FieldError{
+				{Field: "items[1].sku", Rule: "sku", Message: "must start with SKU-"},
+				{Field: "items[1].quantity", Rule: "min", Message: "must be at least 1"},
+				{Field: "tags[1]", Rule: "min", Message: "must be at least 2 characters long"},
+				{Field: "address.country", Rule: "len", Message: "must be exactly 2 characters long"},
+				{Field: "labels[team]", Rule: "required", Message: "is required"},
+			},
+		},
+	}
+
+	v := validator.New(validator.WithRule("sku", sku))
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := validOrder()
+			tt.modify(&o)
+
+			err := v.Validate(&o)
+			if tt.wantFields == nil {
+				assert.NoError(t, err)
+				return
+			}
+
+			var ve *kitError.ValidationError
+			require.ErrorAs(t, err, &ve)
+			assert.Equal(t, tt.wantFields, ve.Fields)
+		})
+	}
+}
+
+func TestValidator_Validate_invalidTags(t *testing.T) {
+	tests := []struct {
+		name string
+		i    interface{}
+	}{
+		{
+			name: "unknown rule",
+			i: &struct {
+				Name string `validate:"sku"`
+			}{Name: "a"},
+		},
+		{
+			name: "min on a bool",
+			i: &struct {
+				Active bool `validate:"min=1"`
+			}{},
+		},
+		{
+			name: "parameter is not a number",
+			i: &struct {
+				Name string `validate:"max=many"`
+			}{},
+		},
+		{
+			name: "invalid pattern",
+			i: &struct {
+				Name string `validate:"regex=["`
+			}{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validator.New().Validate(tt.i)
+
+			var he *echo.HTTPError
+			require.ErrorAs(t, err, &he)
+			assert.Equal(t, http.StatusInternalServerError, he.Code)
+			assert.ErrorIs(t, he.Internal, validator.ErrInvalidTag)
+		})
+	}
+}
+
+func TestWithRule_invalidName(t *testing.T) {
+	tests := []struct {
+		name      string
+		rule      string
+		wantPanic string
+	}{
+		{
+			name:      "empty",
+			rule:      "",
+			wantPanic: `validator: rule name "" can't be empty, or contain commas or equal signs`,
+		},
+		{
+			name:      "comma",
+			rule:      "sku,upper",
+			wantPanic: `validator: rule name "sku,upper" can't be empty, or contain commas or equal signs`,
+		},
+		{
+			name:      "equal sign",
+			rule:      "sku=",
+			wantPanic: `validator: rule name "sku=" can't be empty, or contain commas or equal signs`,
+		},
+		{
+			name:      "omitempty",
+			rule:      "omitempty",
+			wantPanic: "validator: rule omitempty can't be replaced",
+		},
+		{
+			name:      "dive",
+			rule:      "dive",
+			wantPanic: "validator: rule dive can't be replaced",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.PanicsWithError(t, tt.wantPanic, func() {
+				validator.WithRule(tt.rule, sku)
+			})
+		})
+	}
+}
+
+func TestValidator_echo(t *testing.T) {
+	type createOrder struct {
+		Email string `json:"email" validate:"required,email"`
+	}
+
+	e := echo.New()
+	e.Validator = validator.New()
+	e.HTTPErrorHandler = kitError.Handler(zerolog.Nop())
+	e.POST("/orders", func(c echo.Context) error {
+		var req createOrder
+		if err := kitError.BindAndValidate(c, &req); err != nil {
+			return err
+		}
+
+		return c.NoContent(http.StatusCreated)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(`{"email":"jane"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	w := httptest.NewRecorder()
+
+	e.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Equal(t, `{"errors":[{"field":"email","rule":"email","message":"must be a valid email address"}],`+
+		`"message":"request validation failed","status":422}`, strings.TrimSpace(w.Body.String()))
+}